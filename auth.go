@@ -39,6 +39,9 @@ type OperationPermissions struct {
 	AllowedRootQueryFields        AllowedFields `json:"query"`
 	AllowedRootMutationFields     AllowedFields `json:"mutation"`
 	AllowedRootSubscriptionFields AllowedFields `json:"subscription"`
+	// AllowUntrustedDocuments allows operations that are not in the trusted
+	// documents safelist, e.g. for developers running ad-hoc queries
+	AllowUntrustedDocuments bool `json:"allow-untrusted-documents"`
 }
 
 type fieldList []string
@@ -103,7 +106,7 @@ func (a *AllowedFields) UnmarshalJSON(input []byte) error {
 
 // MarshalJSON marshals to a JSON representation.
 func (o OperationPermissions) MarshalJSON() ([]byte, error) {
-	m := make(map[string]AllowedFields)
+	m := make(map[string]interface{})
 	if o.AllowedRootQueryFields.AllowAll || o.AllowedRootQueryFields.AllowedSubfields != nil {
 		m["query"] = o.AllowedRootQueryFields
 	}
@@ -113,6 +116,9 @@ func (o OperationPermissions) MarshalJSON() ([]byte, error) {
 	if o.AllowedRootSubscriptionFields.AllowAll || o.AllowedRootSubscriptionFields.AllowedSubfields != nil {
 		m["subscription"] = o.AllowedRootSubscriptionFields
 	}
+	if o.AllowUntrustedDocuments {
+		m["allow-untrusted-documents"] = true
+	}
 	return json.Marshal(m)
 }
 
@@ -308,17 +314,20 @@ func MergePermissions(perms ...OperationPermissions) OperationPermissions {
 	var queries []AllowedFields
 	var mutations []AllowedFields
 	var subscriptions []AllowedFields
+	var allowUntrustedDocuments bool
 
 	for _, p := range perms {
 		queries = append(queries, p.AllowedRootQueryFields)
 		mutations = append(mutations, p.AllowedRootMutationFields)
 		subscriptions = append(subscriptions, p.AllowedRootSubscriptionFields)
+		allowUntrustedDocuments = allowUntrustedDocuments || p.AllowUntrustedDocuments
 	}
 
 	return OperationPermissions{
 		AllowedRootQueryFields:        MergeAllowedFields(queries...),
 		AllowedRootMutationFields:     MergeAllowedFields(mutations...),
 		AllowedRootSubscriptionFields: MergeAllowedFields(subscriptions...),
+		AllowUntrustedDocuments:       allowUntrustedDocuments,
 	}
 }
 
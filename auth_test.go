@@ -595,6 +595,15 @@ func TestMergePermissions(t *testing.T) {
 	assert.EqualValues(t, expected, res)
 }
 
+func TestMergePermissionsAllowUntrustedDocuments(t *testing.T) {
+	trusted := OperationPermissions{AllowedRootQueryFields: AllowedFields{AllowAll: true}}
+	untrusted := OperationPermissions{AllowUntrustedDocuments: true}
+
+	assert.False(t, MergePermissions(trusted, trusted).AllowUntrustedDocuments)
+	assert.True(t, MergePermissions(trusted, untrusted).AllowUntrustedDocuments)
+	assert.True(t, MergePermissions(untrusted, trusted).AllowUntrustedDocuments)
+}
+
 func TestMergeAllowedFields(t *testing.T) {
 	tts := []struct {
 		name     string
@@ -655,6 +664,83 @@ func TestMergeAllowedFields(t *testing.T) {
 	}
 }
 
+var filteredSchemaBenchmarkSchema = `
+	interface Animal { name: String! }
+
+	type Dog implements Animal {
+		name: String!
+		owner: Person
+	}
+
+	type Person {
+		id: ID!
+		name: String!
+		animals: [Animal!]
+		movies: [Movie!]
+	}
+
+	type Movie {
+		id: ID!
+		title: String
+		cast: [Person!]
+		compTitles: [Movie]
+	}
+
+	type Query {
+		movies: [Movie!]
+		people: [Person!]
+		animals: [Animal!]
+	}
+`
+
+var filteredSchemaBenchmarkPermissions = OperationPermissions{
+	AllowedRootQueryFields: AllowedFields{
+		AllowedSubfields: map[string]AllowedFields{
+			"movies": {AllowAll: true},
+			"people": {AllowedSubfields: map[string]AllowedFields{"id": {}, "name": {}}},
+		},
+	},
+}
+
+func TestExecutableSchemaMemoizesFilteredSchemas(t *testing.T) {
+	es := NewExecutableSchema(nil, 50, nil)
+	es.MergedSchema = gqlparser.MustLoadSchema(&ast.Source{Input: filteredSchemaBenchmarkSchema})
+
+	perms := filteredSchemaBenchmarkPermissions
+	schema := es.filteredSchema(perms)
+	assert.Same(t, schema, es.filteredSchema(perms))
+	assert.Equal(t, formatSchema(perms.FilterSchema(es.MergedSchema)), formatSchema(schema))
+
+	other := OperationPermissions{AllowedRootQueryFields: AllowedFields{AllowAll: true}}
+	assert.NotSame(t, schema, es.filteredSchema(other))
+
+	es.filteredSchemas.Purge()
+	assert.NotSame(t, schema, es.filteredSchema(perms))
+}
+
+func BenchmarkFilterSchema(b *testing.B) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: filteredSchemaBenchmarkSchema})
+	perms := filteredSchemaBenchmarkPermissions
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		perms.FilterSchema(schema)
+	}
+}
+
+func BenchmarkExecutableSchemaFilteredSchema(b *testing.B) {
+	es := NewExecutableSchema(nil, 50, nil)
+	es.MergedSchema = gqlparser.MustLoadSchema(&ast.Source{Input: filteredSchemaBenchmarkSchema})
+	perms := filteredSchemaBenchmarkPermissions
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		es.filteredSchema(perms)
+	}
+}
+
 func strToSelectionSet(schema *ast.Schema, query string) ast.SelectionSet {
 	return gqlparser.MustLoadQuery(schema, query).Operations[0].SelectionSet
 }
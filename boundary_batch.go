@@ -0,0 +1,54 @@
+package bramble
+
+import "fmt"
+
+const (
+	// BoundaryLookupArray prefers array boundary fields (e.g. movies(ids: [ID!]!))
+	BoundaryLookupArray = "array"
+	// BoundaryLookupSingle prefers single boundary fields (e.g. movie(id: ID!)),
+	// queried with one aliased selection per id
+	BoundaryLookupSingle = "single"
+
+	defaultBoundaryBatchSize          = 50
+	defaultBoundaryMaxParallelBatches = 5
+)
+
+// BoundaryBatchConfig configures how the boundary queries to a service are
+// batched
+type BoundaryBatchConfig struct {
+	BatchSize          int    `json:"batch-size"`           // BatchSize is the number of ids per boundary query, defaults to 50 for single lookups and unlimited for array lookups.
+	MaxParallelBatches int    `json:"max-parallel-batches"` // MaxParallelBatches is the number of batches queried concurrently, defaults to 5.
+	Lookup             string `json:"lookup"`               // Lookup is the preferred boundary field when a service has both, "array" (default) or "single".
+}
+
+// validate returns an error if the configuration is invalid
+func (c BoundaryBatchConfig) validate() error {
+	switch c.Lookup {
+	case "", BoundaryLookupArray, BoundaryLookupSingle:
+	default:
+		return fmt.Errorf("invalid boundary lookup %q", c.Lookup)
+	}
+	if c.BatchSize < 0 || c.MaxParallelBatches < 0 {
+		return fmt.Errorf("boundary batch size and max parallel batches must be positive")
+	}
+	return nil
+}
+
+// batches splits the ids in the batches of the boundary queries
+func (c BoundaryBatchConfig) batches(ids []string, array bool) [][]string {
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		if array {
+			return [][]string{ids}
+		}
+		batchSize = defaultBoundaryBatchSize
+	}
+	return batchBy(ids, batchSize)
+}
+
+func (c BoundaryBatchConfig) maxParallelBatches() int {
+	if c.MaxParallelBatches <= 0 {
+		return defaultBoundaryMaxParallelBatches
+	}
+	return c.MaxParallelBatches
+}
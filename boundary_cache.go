@@ -0,0 +1,149 @@
+package bramble
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const defaultBoundaryCacheSize = 10000
+
+// BoundaryCacheConfig contains the boundary cache configuration
+type BoundaryCacheConfig struct {
+	Size int               `json:"size"` // Size is the number of entities kept in the cache.
+	TTLs map[string]string `json:"ttls"` // TTLs are the durations boundary types are cached for, keyed by type name.
+}
+
+// NewBoundaryCache returns the boundary cache for the configuration, or nil if
+// no boundary type is cached.
+func (c BoundaryCacheConfig) NewBoundaryCache() (*BoundaryCache, error) {
+	if len(c.TTLs) == 0 {
+		return nil, nil
+	}
+
+	ttls := make(map[string]time.Duration, len(c.TTLs))
+	for typeName, ttl := range c.TTLs {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boundary cache ttl for %q: %w", typeName, err)
+		}
+		ttls[typeName] = duration
+	}
+
+	size := c.Size
+	if size <= 0 {
+		size = defaultBoundaryCacheSize
+	}
+	return NewBoundaryCache(size, ttls)
+}
+
+// BoundaryCache is an LRU cache of boundary query results. Entities are
+// cached per service, type, id and selection set, only the types with a TTL
+// are cached.
+//
+// Boundary results are modified when merged in the response, the cache stores
+// and returns copies of the results.
+type BoundaryCache struct {
+	cache *lru.Cache[string, boundaryCacheEntry]
+	ttls  map[string]time.Duration
+	now   func() time.Time
+}
+
+type boundaryCacheEntry struct {
+	value   map[string]interface{}
+	expires time.Time
+}
+
+// NewBoundaryCache returns a boundary cache holding up to size entities
+func NewBoundaryCache(size int, ttls map[string]time.Duration) (*BoundaryCache, error) {
+	cache, err := lru.New[string, boundaryCacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("error creating boundary cache: %w", err)
+	}
+	return &BoundaryCache{cache: cache, ttls: ttls, now: time.Now}, nil
+}
+
+// enabled returns whether the type is cached
+func (c *BoundaryCache) enabled(typeName string) bool {
+	return c != nil && c.ttls[typeName] > 0
+}
+
+// boundaryCacheKeyPrefix returns the prefix of the keys of a boundary step,
+// the id is appended to get the key of an entity.
+func boundaryCacheKeyPrefix(serviceURL, typeName, selectionSet string, variables map[string]interface{}) (string, error) {
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", serviceURL, typeName, selectionSet)
+	hash.Write(vars)
+	return hex.EncodeToString(hash.Sum(nil)) + ":", nil
+}
+
+// get returns the cached entities and the ids missing from the cache
+func (c *BoundaryCache) get(typeName, keyPrefix string, ids []string) ([]interface{}, []string) {
+	var hits []interface{}
+	var misses []string
+	now := c.now()
+	for _, id := range ids {
+		entry, ok := c.cache.Get(keyPrefix + id)
+		if !ok || !now.Before(entry.expires) {
+			misses = append(misses, id)
+			continue
+		}
+		hits = append(hits, copyBoundaryResult(entry.value))
+	}
+
+	promBoundaryCacheRequests.WithLabelValues(typeName, "hit").Add(float64(len(hits)))
+	promBoundaryCacheRequests.WithLabelValues(typeName, "miss").Add(float64(len(misses)))
+	return hits, misses
+}
+
+// add caches the non-nil results of a boundary query
+func (c *BoundaryCache) add(typeName, keyPrefix string, results []interface{}) {
+	expires := c.now().Add(c.ttls[typeName])
+	for _, result := range results {
+		value, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, err := boundaryIDFromMap(value)
+		if err != nil {
+			continue
+		}
+		c.cache.Add(keyPrefix+id, boundaryCacheEntry{
+			value:   copyBoundaryResult(value).(map[string]interface{}),
+			expires: expires,
+		})
+	}
+}
+
+func (c *BoundaryCache) purge() {
+	c.cache.Purge()
+}
+
+// copyBoundaryResult returns a deep copy of the result
+func copyBoundaryResult(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = copyBoundaryResult(v)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, v := range value {
+			result[i] = copyBoundaryResult(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,263 @@
+package bramble
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundaryCache(t *testing.T) {
+	cache, err := NewBoundaryCache(10, map[string]time.Duration{"Movie": time.Minute})
+	require.NoError(t, err)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	assert.True(t, cache.enabled("Movie"))
+	assert.False(t, cache.enabled("Person"))
+	var noCache *BoundaryCache
+	assert.False(t, noCache.enabled("Movie"))
+
+	prefix, err := boundaryCacheKeyPrefix("http://movies", "Movie", "{ title }", nil)
+	require.NoError(t, err)
+	otherPrefix, err := boundaryCacheKeyPrefix("http://movies", "Movie", "{ title release }", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, prefix, otherPrefix)
+
+	cache.add("Movie", prefix, []interface{}{
+		map[string]interface{}{"_bramble_id": "1", "title": "Movie 1", "tags": []interface{}{"a"}},
+		nil,
+	})
+
+	hits, misses := cache.get("Movie", prefix, []string{"1", "2"})
+	assert.Equal(t, []string{"2"}, misses)
+	require.Len(t, hits, 1)
+	assert.Equal(t, map[string]interface{}{"_bramble_id": "1", "title": "Movie 1", "tags": []interface{}{"a"}}, hits[0])
+
+	hits[0].(map[string]interface{})["title"] = "modified"
+	hits[0].(map[string]interface{})["tags"].([]interface{})[0] = "b"
+	hits, _ = cache.get("Movie", prefix, []string{"1"})
+	assert.Equal(t, map[string]interface{}{"_bramble_id": "1", "title": "Movie 1", "tags": []interface{}{"a"}}, hits[0], "cached entities should not be modified by callers")
+
+	_, misses = cache.get("Movie", otherPrefix, []string{"1"})
+	assert.Equal(t, []string{"1"}, misses, "entities are cached per selection set")
+
+	now = now.Add(time.Minute)
+	_, misses = cache.get("Movie", prefix, []string{"1"})
+	assert.Equal(t, []string{"1"}, misses, "expired entities should be missing")
+}
+
+func TestBoundaryCacheConfig(t *testing.T) {
+	cache, err := BoundaryCacheConfig{}.NewBoundaryCache()
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	cache, err = BoundaryCacheConfig{TTLs: map[string]string{"Movie": "30s"}}.NewBoundaryCache()
+	require.NoError(t, err)
+	assert.True(t, cache.enabled("Movie"))
+
+	_, err = BoundaryCacheConfig{TTLs: map[string]string{"Movie": "soon"}}.NewBoundaryCache()
+	assert.Error(t, err)
+}
+
+func TestQueryExecutionWithBoundaryCache(t *testing.T) {
+	movieIDs := []string{"1", "2"}
+	var mutex sync.Mutex
+	var requestedIDs [][]string
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				type Query {
+					movies: [Movie!]!
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var movies []string
+					for _, id := range movieIDs {
+						movies = append(movies, fmt.Sprintf(`{"_bramble_id": %q, "_bramble__typename": "Movie", "id": %q}`, id, id))
+					}
+					fmt.Fprintf(w, `{"data": {"movies": [%s]}}`, strings.Join(movies, ","))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+
+					var ids []string
+					data := map[string]interface{}{}
+					for i := 0; req.Variables[fmt.Sprintf("_bramble_id_%d", i)] != nil; i++ {
+						id := req.Variables[fmt.Sprintf("_bramble_id_%d", i)].(string)
+						ids = append(ids, id)
+						data[fmt.Sprintf("_%d", i)] = map[string]interface{}{
+							"_bramble_id":        id,
+							"_bramble__typename": "Movie",
+							"title":              "Movie " + id,
+						}
+					}
+					// boundary ids are deduplicated in no particular order
+					sort.Strings(ids)
+					mutex.Lock()
+					requestedIDs = append(requestedIDs, ids)
+					mutex.Unlock()
+
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+				}),
+			},
+		},
+		query: `{
+			movies {
+				id
+				title
+			}
+		}`,
+		expected: `{
+			"movies": [
+				{ "id": "1", "title": "Movie 1" },
+				{ "id": "2", "title": "Movie 2" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	cache, err := NewBoundaryCache(10, map[string]time.Duration{"Movie": time.Minute})
+	require.NoError(t, err)
+	es.BoundaryCache = cache
+
+	f.run(t, es, f.checkSuccess())
+	f.run(t, es, f.checkSuccess())
+
+	movieIDs = []string{"1", "2", "3"}
+	f.expected = `{
+		"movies": [
+			{ "id": "1", "title": "Movie 1" },
+			{ "id": "2", "title": "Movie 2" },
+			{ "id": "3", "title": "Movie 3" }
+		]
+	}`
+	f.run(t, es, f.checkSuccess())
+
+	assert.Equal(t, [][]string{{"1", "2"}, {"3"}}, requestedIDs, "only the ids missing from the cache should be requested")
+}
+
+func TestQueryExecutionWithBoundaryCacheAndFailingService(t *testing.T) {
+	movieIDs := []string{"1", "2"}
+	failing := false
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				type Query {
+					movies: [Movie!]!
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var movies []string
+					for _, id := range movieIDs {
+						movies = append(movies, fmt.Sprintf(`{"_bramble_id": %q, "_bramble__typename": "Movie", "id": %q}`, id, id))
+					}
+					fmt.Fprintf(w, `{"data": {"movies": [%s]}}`, strings.Join(movies, ","))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if failing {
+						fmt.Fprint(w, `{"errors": [{"message": "movie service failed"}]}`)
+						return
+					}
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+
+					data := map[string]interface{}{}
+					for i := 0; req.Variables[fmt.Sprintf("_bramble_id_%d", i)] != nil; i++ {
+						id := req.Variables[fmt.Sprintf("_bramble_id_%d", i)].(string)
+						data[fmt.Sprintf("_%d", i)] = map[string]interface{}{
+							"_bramble_id":        id,
+							"_bramble__typename": "Movie",
+							"title":              "Movie " + id,
+						}
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+				}),
+			},
+		},
+		query: `{
+			movies {
+				id
+				title
+			}
+		}`,
+		expected: `{
+			"movies": [
+				{ "id": "1", "title": "Movie 1" },
+				{ "id": "2", "title": "Movie 2" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	cache, err := NewBoundaryCache(10, map[string]time.Duration{"Movie": time.Minute})
+	require.NoError(t, err)
+	es.BoundaryCache = cache
+
+	f.run(t, es, f.checkSuccess())
+
+	movieIDs = []string{"1", "2", "3"}
+	failing = true
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		jsonEqWithOrder(t, `{
+			"movies": [
+				{ "id": "1", "title": "Movie 1" },
+				{ "id": "2", "title": "Movie 2" },
+				{ "id": "3", "title": null }
+			]
+		}`, string(resp.Data))
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "movie service failed", resp.Errors[0].Message)
+		assert.Equal(t, []string{"3"}, resp.Errors[0].Extensions["boundaryIds"], "the error should only concern the ids missing from the cache")
+	})
+}
@@ -0,0 +1,309 @@
+package bramble
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	cacheScopePrivate = "PRIVATE"
+
+	defaultResponseCacheSize = 1000
+)
+
+// ResponseCache stores whole query responses. Only responses without errors
+// and with a public cache hint are stored.
+type ResponseCache interface {
+	// Get returns the response data stored for the key
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores the response data for the key for the given duration
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration)
+}
+
+// cacheHint is the cache policy of a field or an operation, as declared
+// with the @cacheControl directive
+type cacheHint struct {
+	maxAge    int
+	hasMaxAge bool
+	private   bool
+}
+
+// restrict restricts the hint to satisfy the other hint
+func (h *cacheHint) restrict(other cacheHint) {
+	if other.hasMaxAge && (!h.hasMaxAge || other.maxAge < h.maxAge) {
+		h.maxAge = other.maxAge
+		h.hasMaxAge = true
+	}
+	h.private = h.private || other.private
+}
+
+// header returns the value of the Cache-Control header for the hint, an
+// empty value is returned if the response can't be cached
+func (h cacheHint) header() string {
+	if !h.hasMaxAge || h.maxAge <= 0 {
+		return ""
+	}
+	scope := "public"
+	if h.private {
+		scope = "private"
+	}
+	return fmt.Sprintf("max-age=%d, %s", h.maxAge, scope)
+}
+
+// directive returns the @cacheControl directive for the hint
+func (h cacheHint) directive(definition *ast.DirectiveDefinition) *ast.Directive {
+	directive := &ast.Directive{
+		Name:       cacheControlDirectiveName,
+		Definition: definition,
+		Location:   ast.LocationObject,
+	}
+	if h.hasMaxAge {
+		directive.Arguments = append(directive.Arguments, &ast.Argument{
+			Name:  "maxAge",
+			Value: &ast.Value{Kind: ast.IntValue, Raw: strconv.Itoa(h.maxAge)},
+		})
+	}
+	if h.private {
+		directive.Arguments = append(directive.Arguments, &ast.Argument{
+			Name:  "scope",
+			Value: &ast.Value{Kind: ast.EnumValue, Raw: cacheScopePrivate},
+		})
+	}
+	return directive
+}
+
+// cacheHintFromDirectives returns the hint declared by the @cacheControl
+// directive, if present
+func cacheHintFromDirectives(directives ast.DirectiveList) (cacheHint, bool) {
+	directive := directives.ForName(cacheControlDirectiveName)
+	if directive == nil {
+		return cacheHint{}, false
+	}
+
+	var hint cacheHint
+	if arg := directive.Arguments.ForName("maxAge"); arg != nil && arg.Value != nil {
+		if maxAge, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			hint.maxAge = maxAge
+			hint.hasMaxAge = true
+		}
+	}
+	if arg := directive.Arguments.ForName("scope"); arg != nil && arg.Value != nil {
+		hint.private = arg.Value.Raw == cacheScopePrivate
+	}
+	return hint, true
+}
+
+// mergeCacheControlDirectives returns the most restrictive @cacheControl
+// directive of the two definitions
+func mergeCacheControlDirectives(a, b *ast.Definition) ast.DirectiveList {
+	da := a.Directives.ForName(cacheControlDirectiveName)
+	db := b.Directives.ForName(cacheControlDirectiveName)
+	switch {
+	case da == nil && db == nil:
+		return nil
+	case db == nil:
+		return ast.DirectiveList{da}
+	case da == nil:
+		return ast.DirectiveList{db}
+	}
+
+	hint, _ := cacheHintFromDirectives(a.Directives)
+	hintB, _ := cacheHintFromDirectives(b.Directives)
+	hint.restrict(hintB)
+	return ast.DirectiveList{hint.directive(da.Definition)}
+}
+
+// cacheControlHint returns the cache hint of the operation. The max-age of a
+// query is the lowest max-age of its fields, root fields and fields returning
+// composite types without hints have a max-age of 0. Scalar fields without
+// hints don't restrict the max-age. The schema mutex must be held by the
+// caller.
+func (s *ExecutableSchema) cacheControlHint(operation *ast.OperationDefinition) cacheHint {
+	hint := cacheHint{}
+	if operation.Operation != ast.Query {
+		return hint
+	}
+
+	s.restrictCacheHint(&hint, operation.SelectionSet, true)
+	return hint
+}
+
+func (s *ExecutableSchema) restrictCacheHint(hint *cacheHint, selectionSet ast.SelectionSet, root bool) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name == "__typename" {
+				continue
+			}
+			if isGraphQLBuiltinName(selection.Name) || selection.Definition == nil {
+				hint.restrict(cacheHint{hasMaxAge: true})
+				continue
+			}
+
+			returnType := s.MergedSchema.Types[selection.Definition.Type.Name()]
+			fieldHint, ok := cacheHintFromDirectives(selection.Definition.Directives)
+			if !ok && returnType != nil {
+				fieldHint, _ = cacheHintFromDirectives(returnType.Directives)
+			}
+
+			// namespaces only group fields, their fields are considered root
+			// fields
+			namespace := returnType != nil && isNamespaceObject(returnType)
+			if !fieldHint.hasMaxAge && !namespace && (root || (returnType != nil && returnType.IsCompositeType())) {
+				fieldHint.hasMaxAge = true
+				fieldHint.maxAge = 0
+			}
+			hint.restrict(fieldHint)
+
+			s.restrictCacheHint(hint, selection.SelectionSet, namespace)
+		case *ast.InlineFragment:
+			s.restrictCacheHint(hint, selection.SelectionSet, root)
+		case *ast.FragmentSpread:
+			s.restrictCacheHint(hint, selection.Definition.SelectionSet, root)
+		}
+	}
+}
+
+// responseCacheKey returns the key of the response in the response cache, an
+// empty key is returned if the response can't be cached. The operation must
+// have been filtered by the permissions. Responses of the traffic split routes
+// are cached separately.
+func (s *ExecutableSchema) responseCacheKey(operation *ast.OperationDefinition, variables map[string]interface{}, perms OperationPermissions, hasPerms bool, routes map[string]string, hint cacheHint) string {
+	if s.ResponseCache == nil || hint.header() == "" || hint.private {
+		return ""
+	}
+
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return ""
+	}
+
+	permsKey := "none"
+	if hasPerms {
+		permsKey = perms.Fingerprint()
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n%s\n", s.schemaGeneration, permsKey, routesKey(routes))
+	writeOperation(hash, operation)
+	hash.Write(vars)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// InMemoryResponseCache is an LRU ResponseCache
+type InMemoryResponseCache struct {
+	cache *lru.Cache[string, responseCacheEntry]
+	now   func() time.Time
+}
+
+type responseCacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewInMemoryResponseCache returns a response cache holding up to size responses
+func NewInMemoryResponseCache(size int) (*InMemoryResponseCache, error) {
+	cache, err := lru.New[string, responseCacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("error creating response cache: %w", err)
+	}
+	return &InMemoryResponseCache{cache: cache, now: time.Now}, nil
+}
+
+// Get returns the response data stored for the key, if it hasn't expired
+func (c *InMemoryResponseCache) Get(_ context.Context, key string) ([]byte, bool) {
+	entry, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		c.cache.Remove(key)
+		return nil, false
+	}
+	return entry.data, true
+}
+
+// Set stores the response data for the key for the given duration
+func (c *InMemoryResponseCache) Set(_ context.Context, key string, data []byte, ttl time.Duration) {
+	c.cache.Add(key, responseCacheEntry{data: data, expires: c.now().Add(ttl)})
+}
+
+// getCachedResponse returns the response data from the response cache
+func (s *ExecutableSchema) getCachedResponse(ctx context.Context, key string) ([]byte, bool) {
+	if key == "" {
+		return nil, false
+	}
+	data, ok := s.ResponseCache.Get(ctx, key)
+	if !ok {
+		promResponseCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	promResponseCacheRequests.WithLabelValues("hit").Inc()
+	return data, true
+}
+
+// cacheControlHeader holds the Cache-Control header of the response
+type cacheControlHeader struct {
+	mutex sync.Mutex
+	value string
+}
+
+// setCacheControlHeader sets the Cache-Control header of the response for
+// the hint
+func setCacheControlHeader(ctx context.Context, hint cacheHint) {
+	header, ok := ctx.Value(cacheControlContextKey).(*cacheControlHeader)
+	if !ok {
+		return
+	}
+	header.mutex.Lock()
+	header.value = hint.header()
+	header.mutex.Unlock()
+}
+
+// cacheControlMiddleware writes the Cache-Control header computed during the
+// execution of the query
+func cacheControlMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := &cacheControlHeader{}
+		ctx := context.WithValue(r.Context(), cacheControlContextKey, header)
+
+		var once sync.Once
+		writeHeader := func() {
+			once.Do(func() {
+				header.mutex.Lock()
+				defer header.mutex.Unlock()
+				if header.value != "" {
+					w.Header().Set("Cache-Control", header.value)
+				}
+			})
+		}
+
+		wrapped := httpsnoop.Wrap(w, httpsnoop.Hooks{
+			WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return func(code int) {
+					writeHeader()
+					next(code)
+				}
+			},
+			Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return func(b []byte) (int, error) {
+					writeHeader()
+					return next(b)
+				}
+			},
+		})
+
+		h.ServeHTTP(wrapped, r.WithContext(ctx))
+	})
+}
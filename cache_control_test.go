@@ -0,0 +1,202 @@
+package bramble
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const cacheControlTestSchema = `
+	enum CacheControlScope {
+		PUBLIC
+		PRIVATE
+	}
+
+	directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+	directive @namespace on OBJECT
+
+	type Movie @cacheControl(maxAge: 300) {
+		id: ID!
+		title: String
+		rating: Float @cacheControl(maxAge: 60)
+		viewerRating: Float @cacheControl(scope: PRIVATE)
+		director: Person
+	}
+
+	type Person {
+		name: String
+	}
+
+	type CatalogNamespace @namespace {
+		movies: [Movie!]! @cacheControl(maxAge: 120)
+	}
+
+	type Query {
+		movie(id: ID!): Movie
+		featured: Movie @cacheControl(maxAge: 30)
+		catalog: CatalogNamespace!
+		version: String
+	}
+`
+
+func TestCacheControlHint(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: cacheControlTestSchema})
+	es := &ExecutableSchema{MergedSchema: schema}
+
+	header := func(query string) string {
+		op := gqlparser.MustLoadQuery(schema, query).Operations[0]
+		return es.cacheControlHint(op).header()
+	}
+
+	assert.Equal(t, "max-age=300, public", header(`{ movie(id: "1") { id title __typename } }`), "type hint applies to fields returning the type")
+	assert.Equal(t, "max-age=60, public", header(`{ movie(id: "1") { title rating } }`))
+	assert.Equal(t, "max-age=30, public", header(`{ featured { title } }`), "field hint takes precedence over the type hint")
+	assert.Equal(t, "max-age=300, private", header(`{ movie(id: "1") { ...F } } fragment F on Movie { viewerRating }`))
+	assert.Equal(t, "", header(`{ movie(id: "1") { director { name } } }`), "composite fields default to 0")
+	assert.Equal(t, "", header(`{ version }`), "root fields default to 0")
+	assert.Equal(t, "max-age=120, public", header(`{ catalog { movies { title } } }`), "namespace fields are root fields")
+	assert.Equal(t, "", header(`{ movie(id: "1") { title } __schema { queryType { name } } }`))
+}
+
+func TestMergeCacheControlDirectives(t *testing.T) {
+	serviceA := loadSchema(`
+		enum CacheControlScope {
+			PUBLIC
+			PRIVATE
+		}
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		interface Node { id: ID! }
+
+		type Movie implements Node @boundary @cacheControl(maxAge: 300) {
+			id: ID!
+			title: String @cacheControl(maxAge: 60)
+		}
+
+		type Query {
+			node(id: ID!): Node
+			movie(id: ID!): Movie @boundary
+		}
+	`)
+	serviceB := loadSchema(`
+		enum CacheControlScope {
+			PUBLIC
+			PRIVATE
+		}
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		interface Node { id: ID! }
+
+		type Movie implements Node @boundary @cacheControl(maxAge: 600, scope: PRIVATE) {
+			id: ID!
+			release: Int
+		}
+
+		type Query {
+			node(id: ID!): Node
+			movie(id: ID!): Movie @boundary
+		}
+	`)
+
+	merged, err := MergeSchemas(serviceA, serviceB)
+	require.NoError(t, err)
+	require.NotNil(t, merged.Directives[cacheControlDirectiveName])
+	require.NotNil(t, merged.Types[cacheControlScopeName])
+
+	movie := merged.Types["Movie"]
+	hint, ok := cacheHintFromDirectives(movie.Directives)
+	require.True(t, ok)
+	assert.Equal(t, cacheHint{maxAge: 300, hasMaxAge: true, private: true}, hint)
+	assert.NotNil(t, movie.Directives.ForName(boundaryDirectiveName))
+
+	hint, ok = cacheHintFromDirectives(movie.Fields.ForName("title").Directives)
+	require.True(t, ok)
+	assert.Equal(t, 60, hint.maxAge)
+}
+
+func TestInMemoryResponseCache(t *testing.T) {
+	cache, err := NewInMemoryResponseCache(10)
+	require.NoError(t, err)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	cache.Set(ctx, "key", []byte(`{"movie": null}`), time.Minute)
+
+	data, ok := cache.Get(ctx, "key")
+	require.True(t, ok)
+	assert.Equal(t, `{"movie": null}`, string(data))
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get(ctx, "key")
+	assert.False(t, ok)
+}
+
+func TestQueryExecutionWithResponseCache(t *testing.T) {
+	var calls int32
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: cacheControlTestSchema,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&calls, 1)
+					w.Write([]byte(`{"data": {"movie": {"title": "Test title", "viewerRating": 4.5}}}`))
+				}),
+			},
+		},
+		query: `query q($id: ID!) { movie(id: $id) { title } }`,
+		variables: map[string]interface{}{
+			"id": "1",
+		},
+		expected: `{"movie": {"title": "Test title"}}`,
+	}
+
+	es := f.setup(t)
+	cache, err := NewInMemoryResponseCache(10)
+	require.NoError(t, err)
+	es.ResponseCache = cache
+
+	f.run(t, es, f.checkSuccess())
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, int32(1), calls, "the second response should be served from the cache")
+
+	f.variables = map[string]interface{}{"id": "2"}
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, int32(2), calls, "variables are part of the cache key")
+
+	f.query = `{ movie(id: "1") { title viewerRating } }`
+	f.expected = `{"movie": {"title": "Test title", "viewerRating": 4.5}}`
+	f.run(t, es, f.checkSuccess())
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, int32(4), calls, "private responses should not be cached")
+}
+
+func TestCacheControlMiddleware(t *testing.T) {
+	handler := cacheControlMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCacheControlHeader(r.Context(), cacheHint{maxAge: 60, hasMaxAge: true})
+		w.Write([]byte(`{"data": {}}`))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/query", nil))
+	assert.Equal(t, "max-age=60, public", rr.Header().Get("Cache-Control"))
+
+	handler = cacheControlMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCacheControlHeader(r.Context(), cacheHint{})
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/query", nil))
+	assert.Empty(t, rr.Header().Get("Cache-Control"))
+}
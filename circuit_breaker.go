@@ -0,0 +1,179 @@
+package bramble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a request is not sent because the circuit
+// breaker of the service is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed lets all the requests through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects all the requests
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single request through to probe the service
+	CircuitHalfOpen CircuitState = "half-open"
+
+	defaultCircuitOpenDuration = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures the circuit breakers of the services
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests opening
+	// the circuit, circuit breakers are disabled when 0
+	FailureThreshold     int           `json:"failure-threshold"`
+	OpenDuration         string        `json:"open-duration"`
+	OpenDurationDuration time.Duration `json:"-"`
+}
+
+func (c *CircuitBreakerConfig) load() error {
+	if c.OpenDuration == "" {
+		return nil
+	}
+	var err error
+	c.OpenDurationDuration, err = time.ParseDuration(c.OpenDuration)
+	if err != nil {
+		return fmt.Errorf("invalid circuit breaker open duration: %w", err)
+	}
+	return nil
+}
+
+// newCircuitBreaker returns a circuit breaker for the service, or nil if
+// circuit breakers are disabled
+func (c *CircuitBreakerConfig) newCircuitBreaker(serviceURL string) *CircuitBreaker {
+	if c == nil || c.FailureThreshold <= 0 {
+		return nil
+	}
+	return NewCircuitBreaker(serviceURL, c.FailureThreshold, c.OpenDurationDuration)
+}
+
+// CircuitBreaker stops sending requests to a service after consecutive
+// failures. Once opened, the circuit stays open for the open duration and then
+// lets a single request through. The circuit is closed again if that request
+// succeeds, or reopened if it fails.
+type CircuitBreaker struct {
+	serviceURL       string
+	failureThreshold int
+	openDuration     time.Duration
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// NewCircuitBreaker returns a closed circuit breaker for the service
+func NewCircuitBreaker(serviceURL string, failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	if openDuration <= 0 {
+		openDuration = defaultCircuitOpenDuration
+	}
+	b := &CircuitBreaker{
+		serviceURL:       serviceURL,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+	promServiceCircuitOpenGauge.WithLabelValues(serviceURL).Set(0)
+	return b
+}
+
+// State returns the current state of the circuit, a nil circuit breaker is
+// always closed.
+func (b *CircuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openDuration {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns whether a request can be sent to the service. Every allowed
+// request must be followed by a call to Record.
+func (b *CircuitBreaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record records the result of a request. GraphQL errors and cancelled
+// requests are not counted as failures.
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var gqlErr GraphqlErrors
+	if err != nil && (errors.As(err, &gqlErr) || errors.Is(err, context.Canceled)) {
+		b.probing = false
+		return
+	}
+
+	if err == nil {
+		b.failures = 0
+		b.probing = false
+		b.setState(CircuitClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		b.probing = false
+		b.openedAt = b.now()
+		b.setState(CircuitOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+
+	value := 0.0
+	if state != CircuitClosed {
+		value = 1
+	}
+	promServiceCircuitOpenGauge.WithLabelValues(b.serviceURL).Set(value)
+}
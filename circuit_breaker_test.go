@@ -0,0 +1,161 @@
+package bramble
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("svc", 2, time.Minute)
+	b.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	require.True(t, b.Allow())
+	b.Record(failure)
+	assert.Equal(t, CircuitClosed, b.State())
+
+	t.Run("graphql errors and cancellations are not failures", func(t *testing.T) {
+		require.True(t, b.Allow())
+		b.Record(GraphqlErrors{{Message: "not found"}})
+		require.True(t, b.Allow())
+		b.Record(context.Canceled)
+		assert.Equal(t, CircuitClosed, b.State())
+	})
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		require.True(t, b.Allow())
+		b.Record(failure)
+		assert.Equal(t, CircuitOpen, b.State())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("half-open lets a single request through", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		assert.Equal(t, CircuitHalfOpen, b.State())
+		require.True(t, b.Allow())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("failed probe reopens the circuit", func(t *testing.T) {
+		b.Record(failure)
+		assert.Equal(t, CircuitOpen, b.State())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("successful probe closes the circuit", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		require.True(t, b.Allow())
+		b.Record(nil)
+		assert.Equal(t, CircuitClosed, b.State())
+		assert.True(t, b.Allow())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var disabled *CircuitBreaker
+		assert.True(t, disabled.Allow())
+		disabled.Record(failure)
+		assert.Equal(t, CircuitClosed, disabled.State())
+		assert.Nil(t, (&CircuitBreakerConfig{}).newCircuitBreaker("svc"))
+	})
+}
+
+func TestQueryExecutionWithOpenCircuit(t *testing.T) {
+	var calls int32
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movie: String
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&calls, 1)
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}),
+			},
+			{
+				schema: `type Query {
+					actor: String
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"actor": "Test actor"}}`))
+				}),
+			},
+		},
+		query: `{
+			movie
+			actor
+		}`,
+	}
+
+	es := f.setup(t)
+	for _, service := range es.Services {
+		service.CircuitBreaker = NewCircuitBreaker(service.ServiceURL, 1, time.Minute)
+	}
+
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+		assert.Nil(t, resp.Errors[0].Extensions["circuitOpen"])
+	})
+
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, true, resp.Errors[0].Extensions["circuitOpen"])
+		jsonEqWithOrder(t, `{"movie": null, "actor": "Test actor"}`, string(resp.Data))
+	})
+	assert.Equal(t, int32(1), calls, "the request should be short-circuited")
+}
+
+func TestQueryExecutionWithOpenCircuitAndTrafficSplit(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(target.Close)
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movie: String
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movie": "Test movie"}}`))
+				}),
+			},
+		},
+		query: `{
+			movie
+		}`,
+		expected: `{
+			"movie": "Test movie"
+		}`,
+	}
+
+	es := f.setup(t)
+	var serviceURL string
+	for url, service := range es.Services {
+		serviceURL = url
+		service.CircuitBreaker = NewCircuitBreaker(service.ServiceURL, 1, time.Minute)
+	}
+	es.TrafficSplits = []TrafficSplit{{
+		Service: serviceURL,
+		Targets: []TrafficSplitTarget{{URL: target.URL, Weight: 100}},
+	}}
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+		assert.Nil(t, resp.Errors[0].Extensions["circuitOpen"])
+	})
+
+	es.TrafficSplits = nil
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, CircuitClosed, es.Services[serviceURL].CircuitBreaker.State(), "failures of the split target should not open the circuit of the service")
+}
@@ -3,6 +3,7 @@ package bramble
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,8 +28,17 @@ type GraphQLClient struct {
 	HTTPClient      *http.Client
 	MaxResponseSize int64
 	UserAgent       string
-
-	tracer trace.Tracer
+	// Headers are added to every request, replacing the request headers
+	// with the same name
+	Headers http.Header
+	// RetryPolicy configures the retries of failed queries, queries are not
+	// retried when nil
+	RetryPolicy *RetryPolicy
+
+	timeout      time.Duration
+	tlsConfig    *tls.Config
+	deduplicator *requestDeduplicator
+	tracer       trace.Tracer
 }
 
 // ClientOpt is a function used to set a GraphQL client option
@@ -40,21 +50,7 @@ func NewClient(opts ...ClientOpt) *GraphQLClient {
 }
 
 func NewClientWithPlugins(plugins []Plugin, opts ...ClientOpt) *GraphQLClient {
-	var transport http.RoundTripper = http.DefaultTransport
-	transport = otelhttp.NewTransport(transport)
-
-	c := &GraphQLClient{
-		HTTPClient: &http.Client{
-			Transport: transport,
-			Timeout:   5 * time.Second,
-		},
-		tracer:          otel.GetTracerProvider().Tracer(instrumentationName),
-		MaxResponseSize: 1024 * 1024,
-	}
-
-	for _, opt := range opts {
-		opt(c)
-	}
+	c := newClient(true, opts...)
 
 	for _, plugin := range plugins {
 		c.HTTPClient.Transport = plugin.WrapGraphQLClientTransport(c.HTTPClient.Transport)
@@ -63,17 +59,11 @@ func NewClientWithPlugins(plugins []Plugin, opts ...ClientOpt) *GraphQLClient {
 }
 
 func NewClientWithoutKeepAlive(opts ...ClientOpt) *GraphQLClient {
-	var defaultTransport = http.DefaultTransport.(*http.Transport).Clone()
-	defaultTransport.DisableKeepAlives = true
-
-	var transport http.RoundTripper = defaultTransport
-	transport = otelhttp.NewTransport(transport)
+	return newClient(false, opts...)
+}
 
+func newClient(keepAlive bool, opts ...ClientOpt) *GraphQLClient {
 	c := &GraphQLClient{
-		HTTPClient: &http.Client{
-			Timeout:   5 * time.Second,
-			Transport: transport,
-		},
 		tracer:          otel.GetTracerProvider().Tracer(instrumentationName),
 		MaxResponseSize: 1024 * 1024,
 	}
@@ -82,6 +72,31 @@ func NewClientWithoutKeepAlive(opts ...ClientOpt) *GraphQLClient {
 		opt(c)
 	}
 
+	if c.HTTPClient == nil {
+		var transport http.RoundTripper = http.DefaultTransport
+		if !keepAlive || c.tlsConfig != nil {
+			defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+			defaultTransport.DisableKeepAlives = !keepAlive
+			defaultTransport.TLSClientConfig = c.tlsConfig
+			transport = defaultTransport
+		}
+
+		timeout := 5 * time.Second
+		if c.timeout > 0 {
+			timeout = c.timeout
+		}
+
+		c.HTTPClient = &http.Client{
+			Transport: otelhttp.NewTransport(transport),
+			Timeout:   timeout,
+		}
+	} else if c.timeout > 0 {
+		// don't modify the timeout of a client that might be shared
+		httpClient := *c.HTTPClient
+		httpClient.Timeout = c.timeout
+		c.HTTPClient = &httpClient
+	}
+
 	return c
 }
 
@@ -101,6 +116,44 @@ func WithMaxResponseSize(maxResponseSize int64) ClientOpt {
 	}
 }
 
+// WithTimeout sets the timeout of the requests, including reading the
+// response body.
+func WithTimeout(timeout time.Duration) ClientOpt {
+	return func(s *GraphQLClient) {
+		s.timeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the service. It
+// has no effect when a custom HTTP client is set with WithHTTPClient.
+func WithTLSConfig(config *tls.Config) ClientOpt {
+	return func(s *GraphQLClient) {
+		s.tlsConfig = config
+	}
+}
+
+// WithHeaders sets static headers added to every request.
+func WithHeaders(headers http.Header) ClientOpt {
+	return func(s *GraphQLClient) {
+		s.Headers = headers
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed queries.
+func WithRetryPolicy(policy *RetryPolicy) ClientOpt {
+	return func(s *GraphQLClient) {
+		s.RetryPolicy = policy
+	}
+}
+
+// WithRequestDeduplication enables the deduplication of identical in-flight
+// requests.
+func WithRequestDeduplication(config RequestDeduplicationConfig) ClientOpt {
+	return func(s *GraphQLClient) {
+		s.deduplicator = newRequestDeduplicator(config)
+	}
+}
+
 // WithUserAgent set the user agent used by the client.
 func WithUserAgent(userAgent string) ClientOpt {
 	return func(s *GraphQLClient) {
@@ -145,6 +198,10 @@ func (c *GraphQLClient) Request(ctx context.Context, url string, request *Reques
 		httpReq.Header = request.Headers.Clone()
 	}
 
+	for name, values := range c.Headers {
+		httpReq.Header[name] = values
+	}
+
 	httpReq.Header.Set("Content-Type", "application/json; charset=utf-8")
 	httpReq.Header.Set("Accept", "application/json")
 
@@ -152,6 +209,38 @@ func (c *GraphQLClient) Request(ctx context.Context, url string, request *Reques
 		httpReq.Header.Set("User-Agent", c.UserAgent)
 	}
 
+	maxResponseSize := c.MaxResponseSize
+	if maxResponseSize == 0 {
+		maxResponseSize = math.MaxInt64
+	}
+
+	if c.deduplicator.deduplicates(request, httpReq.Header) {
+		body, err := c.deduplicator.do(ctx, url, buf.Bytes(), httpReq.Header, func(ctx context.Context) ([]byte, error) {
+			res, err := c.do(httpReq.WithContext(ctx), url)
+			if err != nil {
+				return nil, err
+			}
+			defer res.Body.Close()
+			return io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+		})
+		if err != nil {
+			return traceErr(err)
+		}
+		return traceErr(decodeResponse(bytes.NewReader(body), maxResponseSize, out))
+	}
+
+	res, err := c.do(httpReq, url)
+	if err != nil {
+		return traceErr(err)
+	}
+	defer res.Body.Close()
+
+	return traceErr(decodeResponse(res.Body, maxResponseSize, out))
+}
+
+// do sends the HTTP request, the response is returned only if it has a 200
+// status code
+func (c *GraphQLClient) do(httpReq *http.Request, url string) (*http.Response, error) {
 	res, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
 		if os.IsTimeout(err) {
@@ -162,23 +251,24 @@ func (c *GraphQLClient) Request(ctx context.Context, url string, request *Reques
 			// Return raw timeout error to allow caller to handle it since a
 			// downstream caller may want to retry, and they will have to jump
 			// through hoops to detect this error otherwise.
-			return traceErr(err)
+			return nil, err
 		}
-		return traceErr(fmt.Errorf("error during request: %w", err))
+		return nil, fmt.Errorf("error during request: %w", err)
 	}
-	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return traceErr(fmt.Errorf("unexpected response code: %s", res.Status))
+		res.Body.Close()
+		return nil, &ResponseStatusError{StatusCode: res.StatusCode, Status: res.Status}
 	}
 
-	maxResponseSize := c.MaxResponseSize
-	if maxResponseSize == 0 {
-		maxResponseSize = math.MaxInt64
-	}
+	return res, nil
+}
 
+// decodeResponse decodes the GraphQL response in out, reading up to
+// maxResponseSize bytes
+func decodeResponse(body io.Reader, maxResponseSize int64, out interface{}) error {
 	limitReader := io.LimitedReader{
-		R: res.Body,
+		R: body,
 		N: maxResponseSize,
 	}
 
@@ -186,22 +276,33 @@ func (c *GraphQLClient) Request(ctx context.Context, url string, request *Reques
 		Data: out,
 	}
 
-	if err = json.NewDecoder(&limitReader).Decode(&graphqlResponse); err != nil {
+	if err := json.NewDecoder(&limitReader).Decode(&graphqlResponse); err != nil {
 		if errors.Is(err, io.ErrUnexpectedEOF) {
 			if limitReader.N == 0 {
-				return traceErr(fmt.Errorf("response exceeded maximum size of %d bytes", maxResponseSize))
+				return fmt.Errorf("response exceeded maximum size of %d bytes", maxResponseSize)
 			}
 		}
-		return traceErr(fmt.Errorf("error decoding response: %w", err))
+		return fmt.Errorf("error decoding response: %w", err)
 	}
 
 	if len(graphqlResponse.Errors) > 0 {
-		return traceErr(graphqlResponse.Errors)
+		return graphqlResponse.Errors
 	}
 
 	return nil
 }
 
+// ResponseStatusError is returned when the service responds with a non 200
+// status code.
+type ResponseStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *ResponseStatusError) Error() string {
+	return fmt.Sprintf("unexpected response code: %s", e.Status)
+}
+
 // Request is a GraphQL request.
 type Request struct {
 	OperationType string                 `json:"operationType,omitempty"`
@@ -2,11 +2,16 @@ package bramble
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -101,4 +106,103 @@ func TestGraphqlClient(t *testing.T) {
 		require.Error(t, err)
 		assert.Equal(t, "response exceeded maximum size of 1 bytes", err.Error())
 	})
+
+	t.Run("with headers", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "static", r.Header.Get("X-Api-Key"))
+			assert.Equal(t, "forwarded", r.Header.Get("X-Request-Id"))
+			w.Write([]byte(`{ "data": {} }`))
+		}))
+
+		c := NewClient(WithHeaders(http.Header{"X-Api-Key": []string{"static"}}))
+		req := NewRequest("{ test }").WithHeaders(http.Header{
+			"X-Api-Key":    []string{"forwarded"},
+			"X-Request-Id": []string{"forwarded"},
+		})
+		err := c.Request(context.Background(), srv.URL, req, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("with timeout", func(t *testing.T) {
+		assert.Equal(t, 5*time.Second, NewClient().HTTPClient.Timeout)
+		assert.Equal(t, 30*time.Second, NewClientWithoutKeepAlive(WithTimeout(30*time.Second)).HTTPClient.Timeout)
+
+		httpClient := &http.Client{Timeout: time.Second}
+		c := NewClient(WithHTTPClient(httpClient), WithTimeout(time.Minute))
+		assert.Equal(t, time.Minute, c.HTTPClient.Timeout)
+		assert.Equal(t, time.Second, httpClient.Timeout, "custom client should not be modified")
+	})
+
+	t.Run("with tls config", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{ "data": {} }`))
+		}))
+		defer srv.Close()
+
+		err := NewClient().Request(context.Background(), srv.URL, &Request{}, nil)
+		require.Error(t, err, "server certificate should not be trusted by default")
+
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		c := NewClient(WithTLSConfig(&tls.Config{RootCAs: pool}))
+		err = c.Request(context.Background(), srv.URL, &Request{}, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("with request deduplication", func(t *testing.T) {
+		var calls int32
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			w.Write([]byte(`{ "data": { "test": "value" } }`))
+		}))
+		defer srv.Close()
+
+		c := NewClient(WithRequestDeduplication(RequestDeduplicationConfig{
+			ExcludeHeaders: []string{"Authorization"},
+		}))
+
+		requestConcurrently := func(newRequest func() *Request) {
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var res map[string]interface{}
+					err := c.Request(context.Background(), srv.URL, newRequest(), &res)
+					assert.NoError(t, err)
+					assert.Equal(t, "value", res["test"])
+				}()
+			}
+			// let the requests reach the client before responding
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			release = make(chan struct{})
+		}
+
+		requestConcurrently(func() *Request {
+			return NewRequest("{ test }").WithOperationType("query")
+		})
+		assert.Equal(t, int32(1), atomic.SwapInt32(&calls, 0), "identical queries should share a single call")
+
+		requestConcurrently(func() *Request {
+			return NewRequest("mutation { test }").WithOperationType("mutation")
+		})
+		assert.Equal(t, int32(5), atomic.SwapInt32(&calls, 0), "mutations should not be deduplicated")
+
+		requestConcurrently(func() *Request {
+			return NewRequest("{ test }").WithOperationType("query").WithHeaders(http.Header{"Authorization": []string{"token"}})
+		})
+		assert.Equal(t, int32(5), atomic.SwapInt32(&calls, 0), "requests with excluded headers should not be deduplicated")
+
+		c = NewClient(WithRequestDeduplication(RequestDeduplicationConfig{
+			IncludeMutations: true,
+		}))
+		requestConcurrently(func() *Request {
+			return NewRequest("mutation { test }").WithOperationType("mutation")
+		})
+		assert.Equal(t, int32(1), atomic.SwapInt32(&calls, 0), "mutations should be deduplicated when included")
+	})
 }
@@ -0,0 +1,245 @@
+package bramble
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errQueryTooComplexCode = "QUERY_TOO_COMPLEX"
+
+// listSizeArguments are the arguments used to estimate the size of a list
+var listSizeArguments = []string{"first", "last", "limit"}
+
+// maxListSize is the largest list size used to compute costs, list size
+// arguments are chosen by the client
+const maxListSize = math.MaxInt32
+
+// QueryLimits contains the limits applied to incoming operations before they
+// are planned. A zero value disables the corresponding limit.
+type QueryLimits struct {
+	// MaxDepth is the maximum depth of the selection set
+	MaxDepth int `json:"max-depth"`
+	// MaxFields is the maximum number of selected fields, fragments are
+	// counted every time they are spread
+	MaxFields int `json:"max-fields"`
+	// MaxCost is the maximum cost of the operation
+	MaxCost int `json:"max-cost"`
+	// FieldCosts overrides the cost of fields ("Type.field"), taking
+	// precedence over the @cost directive
+	FieldCosts map[string]int `json:"field-costs"`
+	// DefaultListSize is the multiplier used for list fields without a
+	// size argument
+	DefaultListSize int `json:"default-list-size"`
+}
+
+// OperationComplexity is the computed complexity of an operation
+type OperationComplexity struct {
+	Depth  int
+	Fields int
+	Cost   int
+}
+
+// enabled returns whether any limit is configured
+func (l *QueryLimits) enabled() bool {
+	return l != nil && (l.MaxDepth > 0 || l.MaxFields > 0 || l.MaxCost > 0)
+}
+
+// Check computes the complexity of the operation and returns an error if it
+// exceeds any of the limits.
+func (l *QueryLimits) Check(operation *ast.OperationDefinition, variables map[string]interface{}) *gqlerror.Error {
+	if !l.enabled() {
+		return nil
+	}
+
+	complexity := l.Complexity(operation.SelectionSet, variables)
+
+	var reasons []string
+	if l.MaxDepth > 0 && complexity.Depth > l.MaxDepth {
+		reasons = append(reasons, fmt.Sprintf("depth %d exceeds maximum depth %d", complexity.Depth, l.MaxDepth))
+	}
+	if l.MaxFields > 0 && complexity.Fields > l.MaxFields {
+		reasons = append(reasons, fmt.Sprintf("%d fields exceeds maximum field count %d", complexity.Fields, l.MaxFields))
+	}
+	if l.MaxCost > 0 && complexity.Cost > l.MaxCost {
+		reasons = append(reasons, fmt.Sprintf("cost %d exceeds maximum cost %d", complexity.Cost, l.MaxCost))
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return &gqlerror.Error{
+		Message: "operation is too complex: " + strings.Join(reasons, ", "),
+		Extensions: map[string]interface{}{
+			"code":   errQueryTooComplexCode,
+			"depth":  complexity.Depth,
+			"fields": complexity.Fields,
+			"cost":   complexity.Cost,
+		},
+	}
+}
+
+// Complexity returns the depth, the number of fields and the cost of the
+// selection set. The cost of a field is its weight plus the cost of its
+// sub-selections, multiplied by the estimated size of the list for list
+// fields. Introspection fields are ignored.
+func (l *QueryLimits) Complexity(selectionSet ast.SelectionSet, variables map[string]interface{}) OperationComplexity {
+	var result OperationComplexity
+	for _, selection := range selectionSet {
+		var child OperationComplexity
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			child = l.Complexity(selection.SelectionSet, variables)
+			child.Depth++
+			child.Fields++
+			child.Cost = l.fieldCost(selection.ObjectDefinition, selection.Definition, child.Cost, func(name string) (interface{}, bool) {
+				arg := selection.Arguments.ForName(name)
+				if arg == nil {
+					return nil, false
+				}
+				value, err := arg.Value.Value(variables)
+				return value, err == nil
+			})
+		case *ast.InlineFragment:
+			child = l.Complexity(selection.SelectionSet, variables)
+		case *ast.FragmentSpread:
+			child = l.Complexity(selection.Definition.SelectionSet, variables)
+		}
+
+		if child.Depth > result.Depth {
+			result.Depth = child.Depth
+		}
+		result.Fields += child.Fields
+		result.Cost = addCost(result.Cost, child.Cost)
+	}
+	return result
+}
+
+func (l *QueryLimits) fieldCost(parent *ast.Definition, field *ast.FieldDefinition, childCost int, argument func(name string) (interface{}, bool)) int {
+	if field == nil {
+		return childCost
+	}
+
+	multiplier := 1
+	if field.Type.Elem != nil {
+		multiplier = l.listSize(argument)
+	}
+
+	return addCost(l.fieldWeight(parent, field), multiplyCost(multiplier, childCost))
+}
+
+func (l *QueryLimits) fieldWeight(parent *ast.Definition, field *ast.FieldDefinition) int {
+	if parent != nil {
+		if cost, ok := l.FieldCosts[fmt.Sprintf("%s.%s", parent.Name, field.Name)]; ok {
+			return nonNegative(cost)
+		}
+	}
+
+	if d := field.Directives.ForName(costDirectiveName); d != nil {
+		if arg := d.Arguments.ForName("weight"); arg != nil {
+			if weight, err := arg.Value.Value(nil); err == nil {
+				if weight, ok := weight.(int64); ok {
+					return nonNegative(int(weight))
+				}
+			}
+		}
+	}
+
+	return 1
+}
+
+func (l *QueryLimits) listSize(argument func(name string) (interface{}, bool)) int {
+	for _, name := range listSizeArguments {
+		value, ok := argument(name)
+		if !ok {
+			continue
+		}
+		switch value := value.(type) {
+		case int:
+			return clampListSize(float64(value))
+		case int64:
+			return clampListSize(float64(value))
+		case float64:
+			return clampListSize(value)
+		case json.Number:
+			if size, err := value.Float64(); err == nil {
+				return clampListSize(size)
+			}
+		}
+	}
+
+	if l.DefaultListSize > 0 {
+		return l.DefaultListSize
+	}
+	return 1
+}
+
+// clampListSize returns the list size within [0, maxListSize]. Negative sizes
+// are often treated as no limit by the services, they count as maxListSize.
+func clampListSize(size float64) int {
+	if size < 0 || size > maxListSize || math.IsNaN(size) {
+		return maxListSize
+	}
+	return int(size)
+}
+
+// nonNegative returns the weight, or 0 if it is negative so that a field can't
+// lower the cost of the operation
+func nonNegative(weight int) int {
+	if weight < 0 {
+		return 0
+	}
+	return weight
+}
+
+// addCost adds the costs, saturating at math.MaxInt
+func addCost(a, b int) int {
+	if b > 0 && a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// multiplyCost multiplies the list size by the cost, saturating at math.MaxInt
+func multiplyCost(size, cost int) int {
+	if size > 0 && cost > math.MaxInt/size {
+		return math.MaxInt
+	}
+	return size * cost
+}
+
+// Complexity returns the cost of the field, as defined by the query limits
+func (s *ExecutableSchema) Complexity(typeName, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.MergedSchema == nil {
+		return 0, false
+	}
+	parent := s.MergedSchema.Types[typeName]
+	if parent == nil {
+		return 0, false
+	}
+	field := parent.Fields.ForName(fieldName)
+	if field == nil {
+		return 0, false
+	}
+
+	limits := s.QueryLimits
+	if limits == nil {
+		limits = &QueryLimits{}
+	}
+
+	return limits.fieldCost(parent, field, childComplexity, func(name string) (interface{}, bool) {
+		value, ok := args[name]
+		return value, ok && value != nil
+	}), true
+}
@@ -0,0 +1,175 @@
+package bramble
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const complexityTestSchema = `
+	directive @cost(weight: Int!) on FIELD_DEFINITION
+
+	type Cast {
+		name: String!
+	}
+
+	type Movie {
+		id: ID!
+		title: String
+		cast(first: Int): [Cast!]! @cost(weight: 3)
+		compTitles(limit: Int): [Movie!]!
+	}
+
+	type Query {
+		movies(first: Int): [Movie!]!
+		movie(id: ID!): Movie @cost(weight: 10)
+	}
+`
+
+func TestQueryComplexity(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: complexityTestSchema})
+
+	complexity := func(limits *QueryLimits, query string, variables map[string]interface{}) OperationComplexity {
+		op := gqlparser.MustLoadQuery(schema, query).Operations[0]
+		return limits.Complexity(op.SelectionSet, variables)
+	}
+
+	t.Run("scalar fields", func(t *testing.T) {
+		c := complexity(&QueryLimits{}, `{ movie(id: "1") { id title __typename } }`, nil)
+		assert.Equal(t, OperationComplexity{Depth: 2, Fields: 3, Cost: 12}, c)
+	})
+
+	t.Run("list multipliers", func(t *testing.T) {
+		c := complexity(&QueryLimits{}, `{ movies(first: 10) { title cast(first: 5) { name } } }`, nil)
+		// movies: 1 + 10 * (title: 1 + cast: 3 + 5 * name: 1)
+		assert.Equal(t, OperationComplexity{Depth: 3, Fields: 4, Cost: 91}, c)
+	})
+
+	t.Run("list multiplier from variables", func(t *testing.T) {
+		c := complexity(&QueryLimits{}, `query q($n: Int) { movies(first: $n) { title } }`, map[string]interface{}{"n": int64(20)})
+		assert.Equal(t, 21, c.Cost)
+	})
+
+	t.Run("default list size", func(t *testing.T) {
+		c := complexity(&QueryLimits{DefaultListSize: 50}, `{ movies { title compTitles(limit: 2) { id } } }`, nil)
+		// movies: 1 + 50 * (title: 1 + compTitles: 1 + 2 * id: 1)
+		assert.Equal(t, 201, c.Cost)
+	})
+
+	t.Run("negative list size", func(t *testing.T) {
+		c := complexity(&QueryLimits{DefaultListSize: 50}, `{ movies(first: -1) { title cast(first: 5) { name } } }`, nil)
+		// negative sizes may mean no limit, movies: 1 + maxListSize * (title: 1 + cast: 3 + 5 * name: 1)
+		assert.Equal(t, 1+maxListSize*9, c.Cost)
+	})
+
+	t.Run("negative weights", func(t *testing.T) {
+		negativeSchema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+			directive @cost(weight: Int!) on FIELD_DEFINITION
+			type Query {
+				cheap: String @cost(weight: -100)
+				title: String
+			}
+		`})
+		op := gqlparser.MustLoadQuery(negativeSchema, `{ cheap title }`).Operations[0]
+		assert.Equal(t, 1, (&QueryLimits{}).Complexity(op.SelectionSet, nil).Cost)
+		assert.Equal(t, 0, (&QueryLimits{FieldCosts: map[string]int{"Query.title": -5}}).Complexity(op.SelectionSet, nil).Cost)
+	})
+
+	t.Run("very large list size", func(t *testing.T) {
+		c := complexity(&QueryLimits{}, `query q($n: Int) { movies(first: $n) { title compTitles(limit: $n) { compTitles(limit: $n) { id } } } }`, map[string]interface{}{"n": int64(math.MaxInt64)})
+		assert.Equal(t, math.MaxInt, c.Cost)
+	})
+
+	t.Run("config overrides directive", func(t *testing.T) {
+		c := complexity(&QueryLimits{FieldCosts: map[string]int{"Query.movie": 1, "Movie.title": 5}}, `{ movie(id: "1") { title } }`, nil)
+		assert.Equal(t, 6, c.Cost)
+	})
+
+	t.Run("fragments", func(t *testing.T) {
+		c := complexity(&QueryLimits{}, `
+		{
+			movie(id: "1") { ...MovieFragment ... on Movie { id } }
+			other: movie(id: "2") { ...MovieFragment }
+		}
+		fragment MovieFragment on Movie { title cast(first: 2) { name } }`, nil)
+		assert.Equal(t, OperationComplexity{Depth: 3, Fields: 9, Cost: 33}, c)
+	})
+}
+
+func TestQueryLimitsCheck(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: complexityTestSchema})
+	op := gqlparser.MustLoadQuery(schema, `{ movies(first: 10) { compTitles(limit: 10) { title } } }`).Operations[0]
+
+	var noLimits *QueryLimits
+	assert.Nil(t, noLimits.Check(op, nil))
+	assert.Nil(t, (&QueryLimits{MaxDepth: 3, MaxFields: 3, MaxCost: 111}).Check(op, nil))
+
+	err := (&QueryLimits{MaxDepth: 2, MaxCost: 100}).Check(op, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, "operation is too complex: depth 3 exceeds maximum depth 2, cost 111 exceeds maximum cost 100", err.Message)
+	assert.Equal(t, map[string]interface{}{
+		"code":   errQueryTooComplexCode,
+		"depth":  3,
+		"fields": 3,
+		"cost":   111,
+	}, err.Extensions)
+
+	err = (&QueryLimits{MaxFields: 2}).Check(op, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, "operation is too complex: 3 fields exceeds maximum field count 2", err.Message)
+}
+
+func TestQueryLimitsExecution(t *testing.T) {
+	called := false
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: complexityTestSchema,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = true
+					w.Write([]byte(`{"data": {"movies": []}}`))
+				}),
+			},
+		},
+		query: `{ movies(first: 100) { cast(first: 100) { name } } }`,
+	}
+
+	es := f.setup(t)
+	require.NotNil(t, es.MergedSchema.Types["Movie"].Fields.ForName("cast").Directives.ForName(costDirectiveName), "@cost should be kept in the merged schema")
+	es.QueryLimits = &QueryLimits{MaxCost: 1000}
+
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, errQueryTooComplexCode, resp.Errors[0].Extensions["code"])
+		assert.Equal(t, 10301, resp.Errors[0].Extensions["cost"])
+		assert.Nil(t, resp.Data)
+	})
+	assert.False(t, called, "the query should be rejected before execution")
+}
+
+func TestExecutableSchemaComplexity(t *testing.T) {
+	es := NewExecutableSchema(nil, 50, nil)
+	es.MergedSchema = gqlparser.MustLoadSchema(&ast.Source{Input: complexityTestSchema})
+
+	cost, ok := es.Complexity("Query", "movies", 2, map[string]interface{}{"first": 10})
+	assert.True(t, ok)
+	assert.Equal(t, 21, cost)
+
+	cost, ok = es.Complexity("Movie", "cast", 1, map[string]interface{}{"first": nil})
+	assert.True(t, ok)
+	assert.Equal(t, 4, cost)
+
+	es.QueryLimits = &QueryLimits{FieldCosts: map[string]int{"Movie.cast": 7}}
+	cost, ok = es.Complexity("Movie", "cast", 1, map[string]interface{}{})
+	assert.True(t, ok)
+	assert.Equal(t, 8, cost)
+
+	_, ok = es.Complexity("Movie", "unknown", 1, nil)
+	assert.False(t, ok)
+}
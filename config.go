@@ -2,7 +2,10 @@ package bramble
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/fsnotify/fsnotify"
 	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
@@ -33,31 +37,178 @@ type TimeoutConfig struct {
 	IdleTimeoutDuration  time.Duration `json:"-"`
 }
 
+// ServiceConfig contains the configuration of the client used to query a
+// federated service. It is set in the config file as a service object.
+type ServiceConfig struct {
+	URL             string              `json:"url"`
+	Timeout         string              `json:"timeout"`           // Timeout of the requests to the service, defaults to 5s.
+	MaxResponseSize int64               `json:"max-response-size"` // MaxResponseSize overrides the max-service-response-size.
+	Headers         map[string]string   `json:"headers"`           // Headers are added to every request to the service.
+	TLS             ServiceTLSConfig    `json:"tls"`
+	Retry           *RetryPolicy        `json:"retry"`             // Retry overrides the retry policy for the service.
+	Boundary        BoundaryBatchConfig `json:"boundary"`          // Boundary configures the boundary queries to the service.
+	OwnedRootFields []string            `json:"owned-root-fields"` // OwnedRootFields are the shared root fields resolved by the service (e.g. "Query.products"), overriding the @owner directive.
+}
+
+// ServiceTLSConfig contains the TLS configuration used to connect to a service
+type ServiceTLSConfig struct {
+	Cert string `json:"cert"` // Cert is the path to the PEM encoded client certificate.
+	Key  string `json:"key"`  // Key is the path to the PEM encoded client key.
+	CA   string `json:"ca"`   // CA is the path to the PEM encoded CA bundle used to verify the service.
+}
+
+// UnmarshalJSON accepts either a service URL or a service object
+func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*s = ServiceConfig{URL: url}
+		return nil
+	}
+
+	type serviceConfig ServiceConfig
+	var config serviceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("service must be a URL or an object: %w", err)
+	}
+	if config.URL == "" {
+		return errors.New("service url is required")
+	}
+	if err := config.Boundary.validate(); err != nil {
+		return fmt.Errorf("service %s: %w", config.URL, err)
+	}
+	for _, field := range config.OwnedRootFields {
+		if !isRootFieldCoordinate(field) {
+			return fmt.Errorf("service %s: invalid owned root field %q, expected Query.<field> or Mutation.<field>", config.URL, field)
+		}
+	}
+	*s = ServiceConfig(config)
+	return nil
+}
+
+func (s ServiceConfig) hasClientOptions() bool {
+	return s.Timeout != "" || s.MaxResponseSize != 0 || len(s.Headers) > 0 || s.TLS != ServiceTLSConfig{} || s.Retry != nil
+}
+
+// clientOptions returns the options of the clients used for the service
+func (s ServiceConfig) clientOptions() ([]ClientOpt, error) {
+	var opts []ClientOpt
+	if s.Timeout != "" {
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for service %q: %w", s.URL, err)
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if s.MaxResponseSize != 0 {
+		opts = append(opts, WithMaxResponseSize(s.MaxResponseSize))
+	}
+	if len(s.Headers) > 0 {
+		headers := http.Header{}
+		for name, value := range s.Headers {
+			headers.Set(name, value)
+		}
+		opts = append(opts, WithHeaders(headers))
+	}
+	if s.TLS != (ServiceTLSConfig{}) {
+		tlsConfig, err := s.TLS.load()
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls config for service %q: %w", s.URL, err)
+		}
+		opts = append(opts, WithTLSConfig(tlsConfig))
+	}
+	if s.Retry != nil {
+		policy := s.Retry.clone()
+		if err := policy.load(); err != nil {
+			return nil, fmt.Errorf("invalid retry policy for service %q: %w", s.URL, err)
+		}
+		opts = append(opts, WithRetryPolicy(policy))
+	}
+	return opts, nil
+}
+
+func (c ServiceTLSConfig) load() (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if c.Cert != "" || c.Key != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CA != "" {
+		ca, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in CA bundle %q", c.CA)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// PersistedQueriesConfig contains the automatic persisted queries configuration
+type PersistedQueriesConfig struct {
+	Enabled   bool `json:"enabled"`    // Enabled enables automatic persisted queries on the query endpoint.
+	CacheSize int  `json:"cache-size"` // CacheSize is the number of queries kept by the default in-memory cache.
+}
+
+// ResponseCacheConfig contains the response cache configuration
+type ResponseCacheConfig struct {
+	Enabled bool `json:"enabled"` // Enabled enables caching of the responses with a public @cacheControl hint.
+	Size    int  `json:"size"`    // Size is the number of responses kept by the default in-memory cache.
+}
+
 // Config contains the gateway configuration
 type Config struct {
-	IdFieldName            string        `json:"id-field-name"`
-	GatewayListenAddress   string        `json:"gateway-address"`
-	DisableIntrospection   bool          `json:"disable-introspection"`
-	MetricsListenAddress   string        `json:"metrics-address"`
-	PrivateListenAddress   string        `json:"private-address"`
-	GatewayPort            int           `json:"gateway-port"`
-	MetricsPort            int           `json:"metrics-port"`
-	PrivatePort            int           `json:"private-port"`
-	DefaultTimeouts        TimeoutConfig `json:"default-timeouts"`
-	GatewayTimeouts        TimeoutConfig `json:"gateway-timeouts"`
-	PrivateTimeouts        TimeoutConfig `json:"private-timeouts"`
-	Services               []string      `json:"services"`
-	LogLevel               log.Level     `json:"loglevel"`
-	PollInterval           string        `json:"poll-interval"`
+	IdFieldName            string          `json:"id-field-name"`
+	GatewayListenAddress   string          `json:"gateway-address"`
+	DisableIntrospection   bool            `json:"disable-introspection"`
+	MetricsListenAddress   string          `json:"metrics-address"`
+	PrivateListenAddress   string          `json:"private-address"`
+	GatewayPort            int             `json:"gateway-port"`
+	MetricsPort            int             `json:"metrics-port"`
+	PrivatePort            int             `json:"private-port"`
+	DefaultTimeouts        TimeoutConfig   `json:"default-timeouts"`
+	GatewayTimeouts        TimeoutConfig   `json:"gateway-timeouts"`
+	PrivateTimeouts        TimeoutConfig   `json:"private-timeouts"`
+	Services               []string        `json:"services"`
+	ServiceConfigs         []ServiceConfig `json:"-"` // ServiceConfigs are the services configured with an object, in addition to Services.
+	LogLevel               log.Level       `json:"loglevel"`
+	PollInterval           string          `json:"poll-interval"`
 	PollIntervalDuration   time.Duration
-	MaxRequestsPerQuery    int64           `json:"max-requests-per-query"`
-	MaxServiceResponseSize int64           `json:"max-service-response-size"`
-	Telemetry              TelemetryConfig `json:"telemetry"`
-	Plugins                []PluginConfig
+	MaxRequestsPerQuery    int64                      `json:"max-requests-per-query"`
+	MaxServiceResponseSize int64                      `json:"max-service-response-size"`
+	QueryPlanCacheSize     int                        `json:"query-plan-cache-size"`
+	QueryLimits            QueryLimits                `json:"query-limits"`
+	Retry                  RetryPolicy                `json:"retry"`
+	CircuitBreaker         CircuitBreakerConfig       `json:"circuit-breaker"`
+	RequestDeduplication   RequestDeduplicationConfig `json:"request-deduplication"`
+	Telemetry              TelemetryConfig            `json:"telemetry"`
+	PersistedQueries       PersistedQueriesConfig     `json:"persisted-queries"`
+	ResponseCache          ResponseCacheConfig        `json:"response-cache"`
+	BoundaryCache          BoundaryCacheConfig        `json:"boundary-cache"`
+	TrafficSplits          []TrafficSplit             `json:"traffic-splits"`
+	ShadowTraffic          []ShadowTraffic            `json:"shadow-traffic"`
+	// Path to the JSON manifest of trusted documents, only the operations
+	// in the manifest can be executed when set
+	TrustedDocumentsManifest string `json:"trusted-documents"`
+	Plugins                  []PluginConfig
 	// Config extensions that can be shared among plugins
 	Extensions map[string]json.RawMessage
 	// HTTP client to customize for downstream services query
 	QueryHTTPClient *http.Client
+	// Cache used to store automatic persisted queries, defaults to an
+	// in-memory LRU cache of size PersistedQueries.CacheSize
+	PersistedQueryCache graphql.Cache `json:"-"`
+	// Cache used to store responses when the response cache is enabled,
+	// defaults to an in-memory LRU cache of size ResponseCache.Size
+	ResponseCacheStore ResponseCache `json:"-"`
 
 	plugins          []Plugin
 	executableSchema *ExecutableSchema
@@ -65,6 +216,8 @@ type Config struct {
 	tracer           trace.Tracer
 	configFiles      []string
 	linkedFiles      []string
+	trustedDocuments *TrustedDocuments
+	linkedManifest   string
 }
 
 func (c *Config) addrOrPort(addr string, port int) string {
@@ -115,10 +268,6 @@ func (c *Config) Load() error {
 	}
 	c.Plugins = plugins
 
-	if strings.TrimSpace(c.IdFieldName) != "" {
-		IdFieldName = c.IdFieldName
-	}
-
 	logLevel := os.Getenv("BRAMBLE_LOG_LEVEL")
 	if level, err := log.ParseLevel(logLevel); err == nil {
 		c.LogLevel = level
@@ -152,17 +301,67 @@ func (c *Config) Load() error {
 		return err
 	}
 
+	if err := c.Retry.load(); err != nil {
+		return fmt.Errorf("invalid retry policy: %w", err)
+	}
+	if err := c.CircuitBreaker.load(); err != nil {
+		return err
+	}
+	for _, split := range c.TrafficSplits {
+		if err := split.validate(); err != nil {
+			return err
+		}
+	}
+	for _, shadow := range c.ShadowTraffic {
+		if err := shadow.validate(); err != nil {
+			return err
+		}
+	}
+
 	services, err := c.buildServiceList()
 	if err != nil {
 		return err
 	}
 	c.Services = services
 
+	if err := c.loadTrustedDocuments(); err != nil {
+		return err
+	}
+
 	c.plugins = c.ConfigurePlugins()
 
 	return nil
 }
 
+func (c *Config) loadTrustedDocuments() error {
+	if c.TrustedDocumentsManifest == "" {
+		return nil
+	}
+
+	documents, err := LoadTrustedDocumentsManifest(c.TrustedDocumentsManifest)
+	if err != nil {
+		return err
+	}
+
+	if c.trustedDocuments == nil {
+		c.trustedDocuments = NewTrustedDocuments(documents)
+	} else {
+		c.trustedDocuments.Update(documents)
+	}
+
+	if c.watcher != nil {
+		// watch the directory, else we'll lose the watch if the file is relinked
+		if err := c.watcher.Add(filepath.Dir(c.TrustedDocumentsManifest)); err != nil {
+			return fmt.Errorf("error add trusted documents manifest to watcher: %w", err)
+		}
+		if c.linkedManifest == "" {
+			c.linkedManifest, _ = filepath.EvalSymlinks(c.TrustedDocumentsManifest)
+		}
+	}
+
+	return nil
+}
+
 func (c *Config) loadTimeouts(config *TimeoutConfig, name string, defaults TimeoutConfig) error {
 	var err error
 	if config.ReadTimeout != "" {
@@ -195,31 +394,124 @@ func (c *Config) loadTimeouts(config *TimeoutConfig, name string, defaults Timeo
 	return nil
 }
 
+// UnmarshalJSON adds the services set as a URL in the config file to Services
+// and the services set as an object to ServiceConfigs
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type config Config
+	var file struct {
+		*config
+		Services []json.RawMessage `json:"services"`
+	}
+	file.config = (*config)(c)
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Services == nil {
+		return nil
+	}
+
+	c.Services, c.ServiceConfigs = nil, nil
+	for _, data := range file.Services {
+		var url string
+		if err := json.Unmarshal(data, &url); err == nil {
+			c.Services = append(c.Services, url)
+			continue
+		}
+		var service ServiceConfig
+		if err := json.Unmarshal(data, &service); err != nil {
+			return err
+		}
+		c.ServiceConfigs = append(c.ServiceConfigs, service)
+	}
+	return nil
+}
+
 func (c *Config) buildServiceList() ([]string, error) {
+	var services []string
 	serviceSet := map[string]bool{}
-	for _, service := range c.Services {
+	addService := func(service string) {
+		if serviceSet[service] {
+			return
+		}
 		serviceSet[service] = true
+		services = append(services, service)
+	}
+
+	for _, service := range c.serviceURLs() {
+		addService(service)
 	}
 	for _, service := range strings.Fields(os.Getenv("BRAMBLE_SERVICE_LIST")) {
-		serviceSet[service] = true
+		addService(service)
 	}
 	for _, plugin := range c.plugins {
 		ok, path := plugin.GraphqlQueryPath()
 		if ok {
-			service := c.PrivateHttpAddress(path)
-			serviceSet[service] = true
+			addService(c.PrivateHttpAddress(path))
 		}
 	}
-	services := []string{}
-	for service := range serviceSet {
-		services = append(services, service)
-	}
 	if len(services) == 0 {
 		return nil, fmt.Errorf("no services found in BRAMBLE_SERVICE_LIST or %s", c.configFiles)
 	}
 	return services, nil
 }
 
+// serviceURLs returns the URLs of the configured services, set as a URL or
+// as an object
+func (c *Config) serviceURLs() []string {
+	urls := append([]string{}, c.Services...)
+	for _, service := range c.ServiceConfigs {
+		if !containsString(urls, service.URL) {
+			urls = append(urls, service.URL)
+		}
+	}
+	return urls
+}
+
+// serviceConfig returns the configuration of the service, empty if the
+// service is only set as a URL
+func (c *Config) serviceConfig(url string) ServiceConfig {
+	for _, service := range c.ServiceConfigs {
+		if service.URL == url {
+			return service
+		}
+	}
+	return ServiceConfig{URL: url}
+}
+
+// serviceClients returns the clients used to query the services with client
+// options, the other services use the shared query client.
+func (c *Config) serviceClients() (map[string]*GraphQLClient, error) {
+	clients := make(map[string]*GraphQLClient)
+	for _, service := range c.ServiceConfigs {
+		if !service.hasClientOptions() {
+			continue
+		}
+		opts, err := service.clientOptions()
+		if err != nil {
+			return nil, err
+		}
+		clients[service.URL] = NewClientWithPlugins(c.plugins, append(c.queryClientOptions(), opts...)...)
+	}
+	return clients, nil
+}
+
+func (c *Config) queryClientOptions() []ClientOpt {
+	opts := []ClientOpt{
+		WithMaxResponseSize(c.MaxServiceResponseSize),
+		WithUserAgent(GenerateUserAgent("query")),
+	}
+	if c.QueryHTTPClient != nil {
+		opts = append(opts, WithHTTPClient(c.QueryHTTPClient))
+	}
+	if c.Retry.enabled() {
+		opts = append(opts, WithRetryPolicy(c.Retry.clone()))
+	}
+	if c.RequestDeduplication.Enabled {
+		opts = append(opts, WithRequestDeduplication(c.RequestDeduplication))
+	}
+	return opts
+}
+
 // Watch starts watching the config files for change.
 func (c *Config) Watch() {
 	for {
@@ -244,6 +536,16 @@ func (c *Config) Watch() {
 					break
 				}
 			}
+			if c.TrustedDocumentsManifest != "" {
+				if filepath.Clean(e.Name) == filepath.Clean(c.TrustedDocumentsManifest) {
+					shouldUpdate = true
+				}
+				currentFile, _ := filepath.EvalSymlinks(c.TrustedDocumentsManifest)
+				if c.linkedManifest != "" && c.linkedManifest != currentFile {
+					c.linkedManifest = currentFile
+					shouldUpdate = true
+				}
+			}
 
 			if !shouldUpdate {
 				log.Debug("nothing to update")
@@ -272,13 +574,25 @@ func (c *Config) reload() error {
 		log.WithError(err).Error("error reloading config")
 	}
 
-	log.WithField("services", c.Services).Info("config file updated")
+	services := c.serviceURLs()
+	log.WithField("services", services).Info("config file updated")
+
+	clients, err := c.serviceClients()
+	if err != nil {
+		log.WithError(err).Error("error updating service clients")
+	} else {
+		c.executableSchema.UpdateServiceClients(clients)
+	}
+	c.executableSchema.UpdateBoundaryBatching(c.boundaryBatching())
+	c.executableSchema.UpdateRootFieldOwners(c.rootFieldOwners())
+	c.executableSchema.UpdateTrafficSplits(c.TrafficSplits)
+	c.executableSchema.UpdateShadowTraffic(c.ShadowTraffic)
 
-	if err := c.executableSchema.UpdateServiceList(ctx, c.Services); err != nil {
+	if err := c.executableSchema.UpdateServiceList(ctx, services); err != nil {
 		log.WithError(err).Error("error updating services")
 	}
 
-	log.WithField("services", c.Services).Info("updated services")
+	log.WithField("services", services).Info("updated services")
 
 	return nil
 }
@@ -313,6 +627,16 @@ func GetConfig(configFiles []string) (*Config, error) {
 		PollInterval:           "10s",
 		MaxRequestsPerQuery:    50,
 		MaxServiceResponseSize: 1024 * 1024,
+		QueryPlanCacheSize:     1000,
+		PersistedQueries: PersistedQueriesConfig{
+			CacheSize: defaultPersistedQueryCacheSize,
+		},
+		ResponseCache: ResponseCacheConfig{
+			Size: defaultResponseCacheSize,
+		},
+		BoundaryCache: BoundaryCacheConfig{
+			Size: defaultBoundaryCacheSize,
+		},
 
 		watcher:     watcher,
 		tracer:      otel.GetTracerProvider().Tracer(instrumentationName),
@@ -343,6 +667,42 @@ func (c *Config) ConfigurePlugins() []Plugin {
 	return enabledPlugins
 }
 
+// boundaryBatching returns the boundary query configuration of the services
+func (c *Config) boundaryBatching() map[string]BoundaryBatchConfig {
+	batching := make(map[string]BoundaryBatchConfig)
+	for _, service := range c.ServiceConfigs {
+		if service.Boundary != (BoundaryBatchConfig{}) {
+			batching[service.URL] = service.Boundary
+		}
+	}
+	return batching
+}
+
+// rootFieldOwners returns the services owning shared root fields, keyed by
+// field coordinate
+func (c *Config) rootFieldOwners() map[string]string {
+	owners := make(map[string]string)
+	for _, service := range c.ServiceConfigs {
+		for _, field := range service.OwnedRootFields {
+			owners[field] = service.URL
+		}
+	}
+	return owners
+}
+
+// isRootFieldCoordinate returns whether the value is a Query or Mutation field
+// coordinate
+func isRootFieldCoordinate(value string) bool {
+	parent, _, _ := strings.Cut(value, ".")
+	return isFieldCoordinate(value) && (parent == queryObjectName || parent == mutationObjectName)
+}
+
+// isFieldCoordinate returns whether the value is a Type.field coordinate
+func isFieldCoordinate(value string) bool {
+	parent, field, ok := strings.Cut(value, ".")
+	return ok && parent != "" && field != "" && !strings.Contains(field, ".")
+}
+
 // Init initializes the config and does an initial fetch of the services.
 func (c *Config) Init() error {
 	var err error
@@ -351,28 +711,58 @@ func (c *Config) Init() error {
 		return fmt.Errorf("error building service list: %w", err)
 	}
 
-	serviceClientOptions := []ClientOpt{
-		WithMaxResponseSize(c.MaxServiceResponseSize),
-	}
-	if c.QueryHTTPClient != nil {
-		serviceClientOptions = append(serviceClientOptions, WithHTTPClient(c.QueryHTTPClient))
+	serviceClients, err := c.serviceClients()
+	if err != nil {
+		return err
 	}
 
 	var services []*Service
-	for _, s := range c.Services {
-		services = append(services, NewService(s, serviceClientOptions...))
+	for _, url := range c.Services {
+		s := c.serviceConfig(url)
+		serviceClientOptions := []ClientOpt{
+			WithMaxResponseSize(c.MaxServiceResponseSize),
+		}
+		if c.QueryHTTPClient != nil {
+			serviceClientOptions = append(serviceClientOptions, WithHTTPClient(c.QueryHTTPClient))
+		}
+		// service options were validated when creating the service clients
+		opts, _ := s.clientOptions()
+		service := NewService(s.URL, append(serviceClientOptions, opts...)...)
+		service.CircuitBreaker = c.CircuitBreaker.newCircuitBreaker(s.URL)
+		service.IDFieldName = c.IdFieldName
+		services = append(services, service)
 	}
 
-	queryClientOptions := []ClientOpt{
-		WithMaxResponseSize(c.MaxServiceResponseSize),
-		WithUserAgent(GenerateUserAgent("query")),
+	queryClient := NewClientWithPlugins(c.plugins, c.queryClientOptions()...)
+	es := NewExecutableSchema(c.plugins, c.MaxRequestsPerQuery, queryClient, services...)
+	es.ServiceClients = serviceClients
+	if strings.TrimSpace(c.IdFieldName) != "" {
+		es.IDFieldName = c.IdFieldName
+	}
+	es.BoundaryBatching = c.boundaryBatching()
+	es.RootFieldOwners = c.rootFieldOwners()
+	es.TrafficSplits = c.TrafficSplits
+	es.ShadowTraffic = c.ShadowTraffic
+	circuitBreaker := c.CircuitBreaker
+	es.CircuitBreaker = &circuitBreaker
+	es.TrustedDocuments = c.trustedDocuments
+	es.QueryLimits = &c.QueryLimits
+	if c.QueryPlanCacheSize > 0 {
+		es.PlanCache, err = NewQueryPlanCache(c.QueryPlanCacheSize)
+		if err != nil {
+			return err
+		}
 	}
-
-	if c.QueryHTTPClient != nil {
-		queryClientOptions = append(queryClientOptions, WithHTTPClient(c.QueryHTTPClient))
+	if c.ResponseCache.Enabled {
+		es.ResponseCache, err = c.responseCache()
+		if err != nil {
+			return err
+		}
+	}
+	es.BoundaryCache, err = c.BoundaryCache.NewBoundaryCache()
+	if err != nil {
+		return err
 	}
-	queryClient := NewClientWithPlugins(c.plugins, queryClientOptions...)
-	es := NewExecutableSchema(c.plugins, c.MaxRequestsPerQuery, queryClient, services...)
 	err = es.UpdateSchema(context.Background(), true)
 	if err != nil {
 		return err
@@ -390,6 +780,17 @@ func (c *Config) Init() error {
 	return nil
 }
 
+func (c *Config) responseCache() (ResponseCache, error) {
+	if c.ResponseCacheStore != nil {
+		return c.ResponseCacheStore, nil
+	}
+	size := c.ResponseCache.Size
+	if size <= 0 {
+		size = defaultResponseCacheSize
+	}
+	return NewInMemoryResponseCache(size)
+}
+
 type arrayFlags []string
 
 func (a *arrayFlags) String() string {
@@ -1,6 +1,13 @@
 package bramble
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -49,3 +56,126 @@ func TestParseExampleConfig(t *testing.T) {
 	require.Equal(t, 20*time.Second, cfg.GatewayTimeouts.WriteTimeoutDuration)
 	require.Equal(t, 10*time.Second, cfg.PrivateTimeouts.WriteTimeoutDuration)
 }
+
+func TestServiceConfig(t *testing.T) {
+	t.Run("string or object", func(t *testing.T) {
+		var cfg Config
+		err := json.Unmarshal([]byte(`{
+			"services": [
+				"http://service-a/query",
+				{
+					"url": "http://reporting/query",
+					"timeout": "30s",
+					"max-response-size": 2048,
+					"headers": { "x-api-key": "secret" }
+				}
+			]
+		}`), &cfg)
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://service-a/query"}, cfg.Services)
+		require.Equal(t, []ServiceConfig{
+			{
+				URL:             "http://reporting/query",
+				Timeout:         "30s",
+				MaxResponseSize: 2048,
+				Headers:         map[string]string{"x-api-key": "secret"},
+			},
+		}, cfg.ServiceConfigs)
+		require.Equal(t, []string{"http://service-a/query", "http://reporting/query"}, cfg.serviceURLs())
+
+		clients, err := cfg.serviceClients()
+		require.NoError(t, err)
+		require.Len(t, clients, 1)
+		client := clients["http://reporting/query"]
+		require.Equal(t, 30*time.Second, client.HTTPClient.Timeout)
+		require.Equal(t, int64(2048), client.MaxResponseSize)
+		require.Equal(t, "secret", client.Headers.Get("X-Api-Key"))
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		var cfg Config
+		err := json.Unmarshal([]byte(`{ "services": [{ "timeout": "30s" }] }`), &cfg)
+		require.EqualError(t, err, "service url is required")
+	})
+
+	t.Run("boundary batching", func(t *testing.T) {
+		var cfg Config
+		err := json.Unmarshal([]byte(`{
+			"services": [
+				"http://service-a/query",
+				{
+					"url": "http://service-b/query",
+					"boundary": { "batch-size": 10, "max-parallel-batches": 2, "lookup": "single" }
+				}
+			]
+		}`), &cfg)
+		require.NoError(t, err)
+		require.Equal(t, map[string]BoundaryBatchConfig{
+			"http://service-b/query": {BatchSize: 10, MaxParallelBatches: 2, Lookup: BoundaryLookupSingle},
+		}, cfg.boundaryBatching())
+
+		err = json.Unmarshal([]byte(`{ "services": [{ "url": "http://service-b/query", "boundary": { "lookup": "batch" } }] }`), &cfg)
+		require.EqualError(t, err, `service http://service-b/query: invalid boundary lookup "batch"`)
+	})
+
+	t.Run("owned root fields", func(t *testing.T) {
+		var cfg Config
+		err := json.Unmarshal([]byte(`{
+			"services": [
+				"http://service-a/query",
+				{
+					"url": "http://service-b/query",
+					"owned-root-fields": ["Query.products", "Mutation.addProduct"]
+				}
+			]
+		}`), &cfg)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"Query.products":      "http://service-b/query",
+			"Mutation.addProduct": "http://service-b/query",
+		}, cfg.rootFieldOwners())
+
+		err = json.Unmarshal([]byte(`{ "services": [{ "url": "http://service-b/query", "owned-root-fields": ["Product.name"] }] }`), &cfg)
+		require.EqualError(t, err, `service http://service-b/query: invalid owned root field "Product.name", expected Query.<field> or Mutation.<field>`)
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		cfg := Config{ServiceConfigs: []ServiceConfig{{URL: "http://service-a/query", Timeout: "soon"}}}
+		_, err := cfg.serviceClients()
+		require.ErrorContains(t, err, `invalid timeout for service "http://service-a/query"`)
+	})
+
+	t.Run("environment services are added", func(t *testing.T) {
+		t.Setenv("BRAMBLE_SERVICE_LIST", "http://service-a/query http://service-b/query")
+		cfg := Config{
+			Services:       []string{"http://service-c/query"},
+			ServiceConfigs: []ServiceConfig{{URL: "http://service-a/query", Timeout: "1s"}},
+		}
+		services, err := cfg.buildServiceList()
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://service-c/query", "http://service-a/query", "http://service-b/query"}, services)
+		require.Equal(t, ServiceConfig{URL: "http://service-a/query", Timeout: "1s"}, cfg.serviceConfig("http://service-a/query"))
+		require.Equal(t, ServiceConfig{URL: "http://service-b/query"}, cfg.serviceConfig("http://service-b/query"))
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{ "data": {} }`))
+		}))
+		defer srv.Close()
+
+		ca := filepath.Join(t.TempDir(), "ca.pem")
+		err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600)
+		require.NoError(t, err)
+
+		cfg := Config{ServiceConfigs: []ServiceConfig{{URL: srv.URL, TLS: ServiceTLSConfig{CA: ca}}}}
+		clients, err := cfg.serviceClients()
+		require.NoError(t, err)
+		err = clients[srv.URL].Request(context.Background(), srv.URL, &Request{}, nil)
+		require.NoError(t, err)
+
+		cfg.ServiceConfigs[0].TLS = ServiceTLSConfig{Cert: "missing.pem", Key: "missing.pem"}
+		_, err = cfg.serviceClients()
+		require.ErrorContains(t, err, "error loading client certificate")
+	})
+}
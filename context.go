@@ -8,8 +8,12 @@ import (
 type contextKey string
 type brambleContextKey int
 
-const permissionsContextKey brambleContextKey = 1
-const requestHeaderContextKey brambleContextKey = 2
+const (
+	permissionsContextKey brambleContextKey = iota + 1
+	requestHeaderContextKey
+	incomingRequestHeaderContextKey
+	cacheControlContextKey
+)
 
 // AddPermissionsToContext adds permissions to the request context. If
 // permissions are set the execution will check them against the query.
@@ -47,3 +51,16 @@ func GetOutgoingRequestHeadersFromContext(ctx context.Context) http.Header {
 	h, _ := ctx.Value(requestHeaderContextKey).(http.Header)
 	return h
 }
+
+// AddIncomingRequestHeadersToContext adds the headers of the incoming request
+// to the context
+func AddIncomingRequestHeadersToContext(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, incomingRequestHeaderContextKey, h)
+}
+
+// GetIncomingRequestHeadersFromContext returns the headers of the incoming
+// request
+func GetIncomingRequestHeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(incomingRequestHeaderContextKey).(http.Header)
+	return h
+}
@@ -0,0 +1,100 @@
+package bramble
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// RequestDeduplicationConfig configures the deduplication of identical
+// in-flight downstream requests. Requests are identical when they have the
+// same service URL, document, variables and headers.
+type RequestDeduplicationConfig struct {
+	Enabled bool `json:"enabled"`
+	// IncludeMutations enables the deduplication of mutations, only queries
+	// are deduplicated by default
+	IncludeMutations bool `json:"include-mutations"`
+	// ExcludeHeaders lists headers (e.g. user-specific headers) disabling
+	// the deduplication of the requests carrying them
+	ExcludeHeaders []string `json:"exclude-headers"`
+}
+
+// requestDeduplicator shares a single downstream call between identical
+// in-flight requests
+type requestDeduplicator struct {
+	config RequestDeduplicationConfig
+	group  singleflight.Group
+}
+
+func newRequestDeduplicator(config RequestDeduplicationConfig) *requestDeduplicator {
+	return &requestDeduplicator{config: config}
+}
+
+// deduplicates returns whether the request can share its call with identical
+// in-flight requests
+func (d *requestDeduplicator) deduplicates(request *Request, headers http.Header) bool {
+	if d == nil {
+		return false
+	}
+	switch request.OperationType {
+	case "query":
+	case "mutation":
+		if !d.config.IncludeMutations {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, name := range d.config.ExcludeHeaders {
+		if headers.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// do executes fn once for all the concurrent calls with the same request,
+// the response body returned by fn is shared between them.
+func (d *requestDeduplicator) do(ctx context.Context, url string, body []byte, headers http.Header, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	key := deduplicationKey(url, body, headers)
+	// the call is shared and must not be cancelled when the caller that
+	// started it goes away
+	sharedCtx := context.WithoutCancel(ctx)
+	ch := d.group.DoChan(key, func() (interface{}, error) {
+		return fn(sharedCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Shared {
+			promServiceDeduplicatedRequestCounter.WithLabelValues(url).Inc()
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]byte), nil
+	}
+}
+
+func deduplicationKey(url string, body []byte, headers http.Header) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", url)
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s: %q\n", name, headers[name])
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
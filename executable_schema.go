@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	lru "github.com/hashicorp/golang-lru/v2"
 	log "github.com/sirupsen/logrus"
 	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
@@ -37,6 +40,9 @@ func NewExecutableSchema(plugins []Plugin, maxRequestsPerQuery int64, client *Gr
 		plugins:             plugins,
 		tracer:              otel.GetTracerProvider().Tracer(instrumentationName),
 		MaxRequestsPerQuery: maxRequestsPerQuery,
+		IDFieldName:         defaultIDFieldName,
+		filteredSchemas:     newFilteredSchemaCache(),
+		shadowQueries:       make(chan struct{}, maxShadowQueries),
 	}
 }
 
@@ -49,6 +55,48 @@ type ExecutableSchema struct {
 	BoundaryQueries     BoundaryFieldsMap
 	GraphqlClient       *GraphQLClient
 	MaxRequestsPerQuery int64
+	// IDFieldName is the id field name of the boundary types without a
+	// declared key, "id" when empty
+	IDFieldName string
+	// ServiceClients are the clients used to query specific services, keyed
+	// by service URL. GraphqlClient is used for the other services.
+	ServiceClients map[string]*GraphQLClient
+	// BoundaryBatching configures the boundary queries to specific services,
+	// keyed by service URL. Other services use the default configuration.
+	BoundaryBatching map[string]BoundaryBatchConfig
+	// RootFieldOwners overrides the services resolving shared root fields,
+	// keyed by field coordinate (e.g. "Query.products")
+	RootFieldOwners map[string]string
+	// TrafficSplits send a share of the requests for fields or services to
+	// other URLs
+	TrafficSplits []TrafficSplit
+	// ShadowTraffic mirrors a sample of the queries sent to services to
+	// candidate services, no queries are mirrored when empty
+	ShadowTraffic []ShadowTraffic
+	// CircuitBreaker configures the circuit breakers of the services added
+	// by UpdateServiceList, circuit breakers are disabled when nil
+	CircuitBreaker *CircuitBreakerConfig
+	// TrustedDocuments restricts the operations that can be executed, all
+	// operations are accepted when nil
+	TrustedDocuments *TrustedDocuments
+	// PlanCache caches the query plans, plans are not cached when nil
+	PlanCache *QueryPlanCache
+	// QueryLimits restricts the complexity of the operations, no limits are
+	// applied when nil
+	QueryLimits *QueryLimits
+	// ResponseCache caches the responses of queries with a public
+	// @cacheControl hint, responses are not cached when nil
+	ResponseCache ResponseCache
+	// BoundaryCache caches the results of boundary queries, boundary results
+	// are not cached when nil
+	BoundaryCache *BoundaryCache
+
+	// schemaGeneration is incremented every time the merged schema changes
+	schemaGeneration uint64
+	// filteredSchemas memoizes the merged schema filtered by permissions
+	filteredSchemas *lru.Cache[string, *ast.Schema]
+	// shadowQueries bounds the number of mirrored queries in flight
+	shadowQueries chan struct{}
 
 	tracer  trace.Tracer
 	mutex   sync.RWMutex
@@ -72,7 +120,14 @@ func (s *ExecutableSchema) UpdateServiceList(ctx context.Context, services []str
 		if svc, ok := s.Services[svcURL]; ok {
 			newServices[svcURL] = svc
 		} else {
-			newServices[svcURL] = NewService(svcURL, WithHTTPClient(s.GraphqlClient.HTTPClient))
+			client := s.serviceClient(svcURL)
+			newServices[svcURL] = NewService(svcURL,
+				WithHTTPClient(client.HTTPClient),
+				WithMaxResponseSize(client.MaxResponseSize),
+				WithHeaders(client.Headers),
+			)
+			newServices[svcURL].CircuitBreaker = s.CircuitBreaker.newCircuitBreaker(svcURL)
+			newServices[svcURL].IDFieldName = s.IDFieldName
 		}
 	}
 	s.Services = newServices
@@ -80,6 +135,56 @@ func (s *ExecutableSchema) UpdateServiceList(ctx context.Context, services []str
 	return s.UpdateSchema(ctx, true)
 }
 
+// UpdateServiceClients replaces the clients used to query specific services.
+func (s *ExecutableSchema) UpdateServiceClients(clients map[string]*GraphQLClient) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ServiceClients = clients
+}
+
+// UpdateBoundaryBatching replaces the boundary query configuration of the
+// services. The boundary fields are selected again on the next schema update.
+func (s *ExecutableSchema) UpdateBoundaryBatching(batching map[string]BoundaryBatchConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.BoundaryBatching = batching
+}
+
+// UpdateRootFieldOwners replaces the services resolving shared root fields.
+// The field locations are updated on the next schema update.
+func (s *ExecutableSchema) UpdateRootFieldOwners(owners map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.RootFieldOwners = owners
+}
+
+// UpdateTrafficSplits replaces the traffic splits
+func (s *ExecutableSchema) UpdateTrafficSplits(splits []TrafficSplit) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.TrafficSplits = splits
+}
+
+// UpdateShadowTraffic replaces the shadow traffic configuration
+func (s *ExecutableSchema) UpdateShadowTraffic(shadowTraffic []ShadowTraffic) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ShadowTraffic = shadowTraffic
+}
+
+// serviceClient returns the client used to query the service
+func (s *ExecutableSchema) serviceClient(serviceURL string) *GraphQLClient {
+	if client, ok := s.ServiceClients[serviceURL]; ok {
+		return client
+	}
+	return s.GraphqlClient
+}
+
 // UpdateSchema updates the schema from every service and then update the merged
 // schema.
 func (s *ExecutableSchema) UpdateSchema(ctx context.Context, forceRebuild bool) error {
@@ -141,14 +246,20 @@ func (s *ExecutableSchema) UpdateSchema(ctx context.Context, forceRebuild bool)
 
 	if len(updatedServices) > 0 || forceRebuild {
 		log.Info("rebuilding merged schema")
-		schema, err := MergeSchemas(schemas...)
+		s.mutex.RLock()
+		batching := s.BoundaryBatching
+		rootFieldOwners := s.RootFieldOwners
+		s.mutex.RUnlock()
+
+		schema, err := MergeSchemasWithOptions(s.schemaOptions(services, rootFieldOwners), schemas...)
 		if err != nil {
 			invalidSchema = true
 			return fmt.Errorf("update of service %v caused schema error: %w", updatedServices, err)
 		}
 
-		boundaryQueries := buildBoundaryFieldsMap(services...)
+		boundaryQueries := buildBoundaryFieldsMap(batching, services...)
 		locations := buildFieldURLMap(services...)
+		applyRootFieldOwners(locations, rootFieldOwners, services...)
 		isBoundary := buildIsBoundaryMap(services...)
 
 		s.mutex.Lock()
@@ -156,14 +267,72 @@ func (s *ExecutableSchema) UpdateSchema(ctx context.Context, forceRebuild bool)
 		s.IsBoundary = isBoundary
 		s.MergedSchema = schema
 		s.BoundaryQueries = boundaryQueries
+		s.schemaGeneration++
+		if s.filteredSchemas != nil {
+			s.filteredSchemas.Purge()
+		}
+		if s.PlanCache != nil {
+			s.PlanCache.purge()
+		}
+		if s.BoundaryCache != nil {
+			s.BoundaryCache.purge()
+		}
 		s.mutex.Unlock()
 	}
 
 	return nil
 }
 
+// schemaOptions returns the options merging the schemas of the services. The
+// root field owners are keyed by service URL.
+func (s *ExecutableSchema) schemaOptions(services []*Service, rootFieldOwners map[string]string) SchemaOptions {
+	opts := SchemaOptions{
+		IDFieldName:     s.IDFieldName,
+		RootFieldOwners: make(map[string]string, len(rootFieldOwners)),
+	}
+	for _, service := range services {
+		opts.ServiceNames = append(opts.ServiceNames, service.Name)
+	}
+	for coordinate, serviceURL := range rootFieldOwners {
+		for _, service := range services {
+			if service.ServiceURL == serviceURL {
+				opts.RootFieldOwners[coordinate] = service.Name
+			}
+		}
+	}
+	return opts
+}
+
+// applyRootFieldOwners routes the root fields to the services configured as
+// their owner. Owners not declaring the field are ignored.
+func applyRootFieldOwners(locations FieldURLMap, owners map[string]string, services ...*Service) {
+	for coordinate, serviceURL := range owners {
+		parent, field, _ := strings.Cut(coordinate, ".")
+		declared := false
+		for _, service := range services {
+			if service.ServiceURL != serviceURL || service.Schema == nil {
+				continue
+			}
+			if t := service.Schema.Types[parent]; t != nil && t.Fields.ForName(field) != nil {
+				declared = true
+			}
+		}
+		if !declared {
+			log.WithFields(log.Fields{
+				"field":   coordinate,
+				"service": serviceURL,
+			}).Warn("ignoring root field owner, the service doesn't declare the field")
+			continue
+		}
+		locations.RegisterURL(parent, field, serviceURL)
+	}
+}
+
 // Exec returns the query execution handler
 func (s *ExecutableSchema) Exec(ctx context.Context) graphql.ResponseHandler {
+	if graphql.GetOperationContext(ctx).Operation.Operation == ast.Subscription {
+		return s.ExecuteSubscription(ctx)
+	}
 	return s.ExecuteQuery
 }
 
@@ -199,6 +368,13 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 	AddField(ctx, "operation.name", operation.Name)
 	AddField(ctx, "operation.type", operation.Operation)
 
+	if err := s.checkTrustedDocument(ctx, operationCtx.RawQuery); err != nil {
+		traceErr(err)
+		return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Errors: gqlerror.List{err},
+		})
+	}
+
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
@@ -209,17 +385,40 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 
 	var errs gqlerror.List
 	perms, hasPerms := GetPermissionsFromContext(ctx)
+	routes := routeTraffic(ctx, s.TrafficSplits)
+	planCacheKey := s.queryPlanCacheKey(operation, perms, hasPerms, routes)
 	if hasPerms {
-		filteredSchema = perms.FilterSchema(s.MergedSchema)
+		filteredSchema = s.filteredSchema(perms)
 		errs = perms.FilterAuthorizedFields(operation)
 	}
 
-	plan, err := Plan(&PlanningContext{
-		Operation:  operation,
-		Schema:     filteredSchema,
-		Locations:  s.Locations,
-		IsBoundary: s.IsBoundary,
-		Services:   s.Services,
+	if err := s.QueryLimits.Check(operation, variables); err != nil {
+		traceErr(err)
+		return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Errors: append(errs, err),
+		})
+	}
+
+	cacheHint := s.cacheControlHint(operation)
+	var responseCacheKey string
+	if len(errs) == 0 {
+		responseCacheKey = s.responseCacheKey(operation, variables, perms, hasPerms, routes, cacheHint)
+	}
+	if data, ok := s.getCachedResponse(ctx, responseCacheKey); ok {
+		setCacheControlHeader(ctx, cacheHint)
+		return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Data: data,
+		})
+	}
+
+	plan, err := s.plan(planCacheKey, &PlanningContext{
+		Operation:   operation,
+		Schema:      filteredSchema,
+		Locations:   s.Locations,
+		IsBoundary:  s.IsBoundary,
+		Services:    s.Services,
+		Routes:      routes,
+		IDFieldName: s.IDFieldName,
 	})
 	if err != nil {
 		traceErr(err)
@@ -250,6 +449,13 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 	executionStart := time.Now()
 
 	qe := newQueryExecution(ctx, operationCtx.OperationName, s.GraphqlClient, filteredSchema, s.BoundaryQueries, int32(s.MaxRequestsPerQuery))
+	qe.serviceClients = s.ServiceClients
+	qe.services = s.Services
+	qe.boundaryCache = s.BoundaryCache
+	qe.boundaryBatching = s.BoundaryBatching
+	qe.routes = plan.Routes
+	qe.shadowTraffic = s.ShadowTraffic
+	qe.shadowQueries = s.shadowQueries
 
 	results, executeErrs := qe.Execute(plan)
 	if len(executeErrs) > 0 {
@@ -276,9 +482,9 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 	}
 
 	timings["execution"] = time.Since(executionStart).String()
+	timings["retries"] = int(atomic.LoadInt32(&qe.retries))
 
-	mergeStart := time.Now()
-	mergedResult, err := mergeExecutionResults(results)
+	formattedResponse, bubbleErrs, err := formatExecutionResults(filteredSchema, operation.SelectionSet, results, timings)
 	if err != nil {
 		errs = append(errs, &gqlerror.Error{Message: err.Error()})
 
@@ -290,30 +496,16 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 		})
 	}
 
-	bubbleErrs, err := bubbleUpNullValuesInPlace(filteredSchema, operation.SelectionSet, mergedResult)
-	if err == errNullBubbledToRoot {
-		mergedResult = nil
-	} else if err != nil {
-		errs = append(errs, &gqlerror.Error{Message: err.Error()})
-
-		traceErr(errs)
-		AddField(ctx, "errors", errs)
-
-		return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
-			Errors: errs,
-		})
-	}
-
 	errs = append(errs, bubbleErrs...)
-	timings["merge"] = time.Since(mergeStart).String()
-
-	formattingStart := time.Now()
-	formattedResponse := formatResponseData(filteredSchema, operation.SelectionSet, mergedResult)
-	timings["format"] = time.Since(formattingStart).String()
 
 	if len(errs) > 0 {
 		traceErr(errs)
 		AddField(ctx, "errors", errs)
+	} else {
+		setCacheControlHeader(ctx, cacheHint)
+		if responseCacheKey != "" {
+			s.ResponseCache.Set(ctx, responseCacheKey, formattedResponse, time.Duration(cacheHint.maxAge)*time.Second)
+		}
 	}
 
 	return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
@@ -322,6 +514,33 @@ func (s *ExecutableSchema) ExecuteQuery(ctx context.Context) *graphql.Response {
 	})
 }
 
+// formatExecutionResults merges the execution results, bubbles up null values
+// and formats the response data. The returned error list contains the errors
+// generated by null bubbling, the error is only set if the results could not
+// be merged.
+func formatExecutionResults(schema *ast.Schema, selectionSet ast.SelectionSet, results []executionResult, timings map[string]interface{}) ([]byte, gqlerror.List, error) {
+	mergeStart := time.Now()
+	mergedResult, err := mergeExecutionResults(results)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bubbleErrs, err := bubbleUpNullValuesInPlace(schema, selectionSet, mergedResult)
+	if err == errNullBubbledToRoot {
+		mergedResult = nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	timings["merge"] = time.Since(mergeStart).String()
+
+	formattingStart := time.Now()
+	formattedResponse := formatResponseData(schema, selectionSet, mergedResult)
+	timings["format"] = time.Since(formattingStart).String()
+
+	return formattedResponse, bubbleErrs, nil
+}
+
 func (s *ExecutableSchema) interceptResponse(ctx context.Context, operationName, rawQuery string, variables map[string]interface{}, response *graphql.Response) *graphql.Response {
 	for _, plugin := range s.plugins {
 		response = plugin.InterceptResponse(ctx, operationName, rawQuery, variables, response)
@@ -334,12 +553,6 @@ func (s *ExecutableSchema) Schema() *ast.Schema {
 	return s.MergedSchema
 }
 
-// Complexity returns the query complexity (unimplemented)
-func (s *ExecutableSchema) Complexity(typeName, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
-	// FIXME: TBD
-	return 0, false
-}
-
 func resolveIntrospectionFields(ctx context.Context, selectionSet ast.SelectionSet, filteredSchema *ast.Schema) map[string]interface{} {
 	introspectionResult := make(map[string]interface{})
 	for _, f := range selectionSetToFields(selectionSet) {
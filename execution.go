@@ -2,6 +2,7 @@ package bramble
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -26,21 +27,36 @@ type executionResult struct {
 
 type queryExecution struct {
 	ctx            context.Context
+	parentCtx      context.Context
 	operationName  string
 	schema         *ast.Schema
 	requestCount   int32
+	retries        int32
 	maxRequest     int32
 	graphqlClient  *GraphQLClient
-	boundaryFields BoundaryFieldsMap
+	serviceClients map[string]*GraphQLClient
+	services       map[string]*Service
+	boundaryCache  *BoundaryCache
+	// boundaryBatching configures the boundary queries, keyed by service URL
+	boundaryBatching map[string]BoundaryBatchConfig
+	boundaryFields   BoundaryFieldsMap
+	// routes are the URLs the services are sent to, chosen by the traffic
+	// splits
+	routes map[string]string
+	// shadowTraffic mirrors a sample of the queries to candidate services
+	shadowTraffic []ShadowTraffic
+	// shadowQueries holds a slot per mirrored query in flight
+	shadowQueries chan struct{}
 
 	group   *errgroup.Group
 	results chan executionResult
 }
 
 func newQueryExecution(ctx context.Context, operationName string, client *GraphQLClient, schema *ast.Schema, boundaryFields BoundaryFieldsMap, maxRequest int32) *queryExecution {
-	group, ctx := errgroup.WithContext(ctx)
+	group, groupCtx := errgroup.WithContext(ctx)
 	return &queryExecution{
-		ctx:            ctx,
+		ctx:            groupCtx,
+		parentCtx:      ctx,
 		operationName:  operationName,
 		schema:         schema,
 		graphqlClient:  client,
@@ -51,9 +67,54 @@ func newQueryExecution(ctx context.Context, operationName string, client *GraphQ
 	}
 }
 
+// client returns the client used to query the service
+func (q *queryExecution) client(serviceURL string) *GraphQLClient {
+	if client, ok := q.serviceClients[serviceURL]; ok {
+		return client
+	}
+	return q.graphqlClient
+}
+
+// request sends the request to the service. Failed queries are retried
+// according to the retry policy of the service client, mutations are never
+// retried. Requests are not sent while the circuit breaker of the service
+// receiving them is open, traffic split targets that are not services have no
+// circuit breaker. It returns the number of retries.
+func (q *queryExecution) request(serviceURL string, req *Request, out interface{}) (int, error) {
+	client := q.client(serviceURL)
+	targetURL := q.targetURL(serviceURL)
+	var circuitBreaker *CircuitBreaker
+	if service, ok := q.services[targetURL]; ok {
+		circuitBreaker = service.CircuitBreaker
+	}
+
+	retries := 0
+	for attempt := 1; ; attempt++ {
+		if !circuitBreaker.Allow() {
+			return retries, ErrCircuitOpen
+		}
+		err := client.Request(q.ctx, targetURL, req, out)
+		circuitBreaker.Record(err)
+		if req.OperationType != "query" || !client.RetryPolicy.shouldRetry(attempt, err) || !client.RetryPolicy.wait(q.ctx, attempt) {
+			return retries, err
+		}
+		retries++
+		atomic.AddInt32(&q.retries, 1)
+		promServiceRetryCounter.WithLabelValues(serviceURL).Inc()
+	}
+}
+
+// targetURL returns the URL the requests to the service are sent to
+func (q *queryExecution) targetURL(serviceURL string) string {
+	if url, ok := q.routes[serviceURL]; ok {
+		return url
+	}
+	return serviceURL
+}
+
 func (q *queryExecution) Execute(queryPlan *QueryPlan) ([]executionResult, gqlerror.List) {
-	wg := &sync.WaitGroup{}
 	results := []executionResult{}
+	var serialSteps []*QueryPlanStep
 
 	for _, step := range queryPlan.RootSteps {
 		if step.ServiceURL == internalServiceName {
@@ -71,12 +132,73 @@ func (q *queryExecution) Execute(queryPlan *QueryPlan) ([]executionResult, gqler
 			continue
 		}
 
+		if step.ParentType == mutationObjectName {
+			serialSteps = append(serialSteps, step)
+			continue
+		}
+
+		step := step
+		q.group.Go(func() error {
+			return q.executeRootStep(step)
+		})
+	}
+
+	if len(serialSteps) > 0 {
+		return q.executeSerially(serialSteps, results)
+	}
+
+	return q.waitForResults(results)
+}
+
+// executeSerially executes the root steps one at a time, in order. Each step
+// and its children must be done before the next step starts, as required for
+// mutations.
+func (q *queryExecution) executeSerially(steps []*QueryPlanStep, results []executionResult) ([]executionResult, gqlerror.List) {
+	for i, step := range steps {
+		if i > 0 {
+			// the errgroup context is cancelled once Wait returns, so every
+			// step needs a new group
+			q.group, q.ctx = errgroup.WithContext(q.parentCtx)
+			q.results = make(chan executionResult)
+		}
+
 		step := step
 		q.group.Go(func() error {
 			return q.executeRootStep(step)
 		})
+
+		var errs gqlerror.List
+		results, errs = q.waitForResults(results)
+		if len(errs) > 0 {
+			return nil, errs
+		}
 	}
 
+	return results, nil
+}
+
+// ExecuteSubscriptionEvent executes the child steps of a subscription root
+// step for a single event received from the downstream service.
+func (q *queryExecution) ExecuteSubscriptionEvent(step *QueryPlanStep, data map[string]interface{}, err error) ([]executionResult, gqlerror.List) {
+	reqStart := time.Now()
+	q.group.Go(func() error {
+		q.writeExecutionResult(step, data, err)
+		step.executionResult = &executionStepResult{
+			executed:  true,
+			error:     err,
+			timeTaken: time.Since(reqStart),
+		}
+		if err != nil {
+			return nil
+		}
+		return q.executeChildSteps(step, data)
+	})
+
+	return q.waitForResults(nil)
+}
+
+func (q *queryExecution) waitForResults(results []executionResult) ([]executionResult, gqlerror.List) {
+	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	go func() {
 		for result := range q.results {
@@ -116,20 +238,38 @@ func (q *queryExecution) executeRootStep(step *QueryPlanStep) error {
 		WithOperationType(step.ParentType)
 
 	var data map[string]interface{}
-	err := q.graphqlClient.Request(q.ctx, step.ServiceURL, req, &data)
+	var out interface{} = &data
+	// the raw response of mirrored queries is kept to be compared in the
+	// background
+	shadow, mirrored := q.sampleShadowTraffic(step.ServiceURL)
+	mirrored = mirrored && step.ParentType == queryObjectName
+	var recorded *recordedData
+	if mirrored {
+		recorded = &recordedData{value: &data}
+		out = recorded
+	}
+	retries, err := q.request(step.ServiceURL, req, out)
+	if err == nil && mirrored {
+		q.shadowRootStep(step, shadow, req, recorded.raw)
+	}
 	q.writeExecutionResult(step, data, err)
 	step.executionResult = &executionStepResult{
 		executed:  true,
 		error:     err,
 		timeTaken: time.Since(reqStart),
+		retries:   retries,
 	}
 
 	if err != nil {
 		return nil
 	}
 
+	return q.executeChildSteps(step, data)
+}
+
+func (q *queryExecution) executeChildSteps(step *QueryPlanStep, data map[string]interface{}) error {
 	for _, childStep := range step.Then {
-		boundaryIDs, err := extractAndDedupeBoundaryIDs(data, childStep.InsertionPoint, childStep.ParentType)
+		boundaryIDs, requirements, err := q.extractChildStepInputs(data, childStep.InsertionPoint, childStep)
 		if err != nil {
 			return err
 		}
@@ -139,7 +279,7 @@ func (q *queryExecution) executeRootStep(step *QueryPlanStep) error {
 
 		childStep := childStep
 		q.group.Go(func() error {
-			return q.executeChildStep(childStep, boundaryIDs)
+			return q.executeChildStep(childStep, boundaryIDs, requirements)
 		})
 	}
 	return nil
@@ -158,32 +298,138 @@ func (q *queryExecution) writeExecutionResult(step *QueryPlanStep, data interfac
 	q.results <- result
 }
 
-func (q *queryExecution) executeChildStep(step *QueryPlanStep, boundaryIDs []string) error {
-	newRequestCount := atomic.AddInt32(&q.requestCount, 1)
-	if newRequestCount > q.maxRequest {
-		return fmt.Errorf("exceeded max requests of %v", q.maxRequest)
+// extractChildStepInputs returns the boundary ids of the child step and, if
+// the step has fields requiring other fields, the required field values by id
+func (q *queryExecution) extractChildStepInputs(data interface{}, insertionPoint []string, step *QueryPlanStep) ([]string, map[string]map[string]interface{}, error) {
+	boundaryIDs, err := extractAndDedupeBoundaryIDs(data, insertionPoint, step.ParentType)
+	if err != nil || len(boundaryIDs) == 0 || len(q.stepRequiredFields(step)) == 0 {
+		return boundaryIDs, nil, err
+	}
+	requirements, err := extractBoundaryRequirements(data, insertionPoint, step.ParentType)
+	return boundaryIDs, requirements, err
+}
+
+// stepRequiredFields returns the fields declared with @requires in the
+// service schema that are selected by the step, with their required fields
+func (q *queryExecution) stepRequiredFields(step *QueryPlanStep) map[string][]string {
+	service, ok := q.services[step.ServiceURL]
+	if !ok || service.Schema == nil || service.Schema.Types[step.ParentType] == nil {
+		return nil
+	}
+	parentDef := service.Schema.Types[step.ParentType]
+	result := map[string][]string{}
+	for _, f := range selectionSetToFields(step.SelectionSet) {
+		if required := requiredFields(parentDef.Fields.ForName(f.Name)); len(required) > 0 {
+			result[f.Name] = required
+		}
 	}
+	return result
+}
+
+func (q *queryExecution) executeChildStep(step *QueryPlanStep, boundaryIDs []string, requirements map[string]map[string]interface{}) error {
 	reqStart := time.Now()
 
-	boundaryField, err := q.boundaryFields.Field(step.ServiceURL, step.ParentType)
-	if err != nil {
-		return err
+	var cached []interface{}
+	var cacheKeyPrefix string
+	// results depending on required fields are not cached, as the field
+	// arguments are not part of the cache key
+	if q.boundaryCache.enabled(step.ParentType) && requirements == nil {
+		_, variables := formatOperation(q.ctx, step.SelectionSet)
+		selectionSet := formatSelectionSetSingleLine(q.ctx, q.schema, step.SelectionSet)
+		prefix, err := boundaryCacheKeyPrefix(q.targetURL(step.ServiceURL), step.ParentType, selectionSet, variables)
+		if err == nil {
+			cacheKeyPrefix = prefix
+			cached, boundaryIDs = q.boundaryCache.get(step.ParentType, cacheKeyPrefix, boundaryIDs)
+		}
 	}
 
-	documents, variables, err := buildBoundaryQueryDocuments(q.ctx, q.schema, step, boundaryIDs, boundaryField, 50)
-	if err != nil {
-		return err
+	var (
+		data    []interface{}
+		retries int
+		err     error
+	)
+	if len(boundaryIDs) > 0 {
+		newRequestCount := atomic.AddInt32(&q.requestCount, 1)
+		if newRequestCount > q.maxRequest {
+			return fmt.Errorf("exceeded max requests of %v", q.maxRequest)
+		}
+
+		boundaryField, fieldErr := q.boundaryFields.Field(step.ServiceURL, step.ParentType)
+		if fieldErr != nil {
+			return fieldErr
+		}
+
+		batching := q.boundaryBatching[step.ServiceURL]
+		var (
+			documents []string
+			variables []map[string]interface{}
+		)
+		// fields with required fields have arguments for each entity, the
+		// boundary field is queried once per entity
+		perEntity := requirements != nil
+		for _, batch := range batching.batches(boundaryIDs, boundaryField.Array && !perEntity) {
+			var (
+				batchDocuments []string
+				batchVariables []map[string]interface{}
+				docErr         error
+			)
+			if perEntity {
+				batchDocuments, batchVariables, docErr = buildRequiresBoundaryQueryDocuments(q.ctx, q.schema, q.services[step.ServiceURL].Schema, step, batch, requirements, boundaryField)
+			} else {
+				batchDocuments, batchVariables, docErr = buildBoundaryQueryDocuments(q.ctx, q.schema, step, batch, boundaryField, len(batch))
+			}
+			if docErr != nil {
+				return docErr
+			}
+			documents = append(documents, batchDocuments...)
+			variables = append(variables, batchVariables...)
+		}
+		if perEntity {
+			boundaryField.Array = false
+		}
+
+		shadow, mirrored := q.sampleShadowTraffic(step.ServiceURL)
+		var raw [][]byte
+		if mirrored {
+			raw = make([][]byte, len(documents))
+		}
+		data, retries, err = q.executeBoundaryQuery(documents, step.ServiceURL, variables, boundaryField, batching.maxParallelBatches(), raw)
+		if err == nil && mirrored {
+			q.shadowChildStep(step, shadow, documents, variables, boundaryField, raw)
+		}
+		if err == nil && cacheKeyPrefix != "" {
+			q.boundaryCache.add(step.ParentType, cacheKeyPrefix, data)
+		}
 	}
+	// cached entities are returned even if the request for the missing ids
+	// failed, the errors then only concern the missing ids
+	data = append(cached, data...)
 
-	data, err := q.executeBoundaryQuery(documents, step.ServiceURL, variables, boundaryField)
-	q.writeExecutionResult(step, data, err)
+	result := executionResult{
+		ServiceURL:     step.ServiceURL,
+		InsertionPoint: step.InsertionPoint,
+		Data:           data,
+	}
+	if err != nil {
+		result.Errors = q.createGQLErrors(step, err)
+		if len(cached) > 0 {
+			for _, e := range result.Errors {
+				if e.Extensions == nil {
+					e.Extensions = make(map[string]interface{})
+				}
+				e.Extensions["boundaryIds"] = boundaryIDs
+			}
+		}
+	}
+	q.results <- result
 	step.executionResult = &executionStepResult{
 		executed:  true,
 		error:     err,
 		timeTaken: time.Since(reqStart),
+		retries:   retries,
 	}
 
-	if err != nil {
+	if err != nil && len(cached) == 0 {
 		return nil
 	}
 
@@ -191,11 +437,17 @@ func (q *queryExecution) executeChildStep(step *QueryPlanStep, boundaryIDs []str
 
 	if len(nonNilBoundaryResults) > 0 {
 		for _, childStep := range step.Then {
-			boundaryResultInsertionPoint, err := trimInsertionPointForNestedBoundaryStep(nonNilBoundaryResults, childStep.InsertionPoint)
-			if err != nil {
-				return err
+			// steps depending on the fields fetched by this step share its
+			// insertion point and use the boundary results directly
+			boundaryResultInsertionPoint := []string{}
+			if !equalInsertionPoints(childStep.InsertionPoint, step.InsertionPoint) {
+				var err error
+				boundaryResultInsertionPoint, err = trimInsertionPointForNestedBoundaryStep(nonNilBoundaryResults, childStep.InsertionPoint)
+				if err != nil {
+					return err
+				}
 			}
-			boundaryIDs, err := extractAndDedupeBoundaryIDs(nonNilBoundaryResults, boundaryResultInsertionPoint, childStep.ParentType)
+			boundaryIDs, requirements, err := q.extractChildStepInputs(nonNilBoundaryResults, boundaryResultInsertionPoint, childStep)
 			if err != nil {
 				return err
 			}
@@ -204,7 +456,7 @@ func (q *queryExecution) executeChildStep(step *QueryPlanStep, boundaryIDs []str
 			}
 			childStep := childStep
 			q.group.Go(func() error {
-				return q.executeChildStep(childStep, boundaryIDs)
+				return q.executeChildStep(childStep, boundaryIDs, requirements)
 			})
 		}
 	}
@@ -224,44 +476,77 @@ func extractNonNilBoundaryResults(data []interface{}) []interface{} {
 	return nonNilResults
 }
 
-func (q *queryExecution) executeBoundaryQuery(documents []string, serviceURL string, variables map[string]interface{}, boundaryFieldGetter BoundaryField) ([]interface{}, error) {
-	output := make([]interface{}, 0)
-	if !boundaryFieldGetter.Array {
-		for _, document := range documents {
+// executeBoundaryQuery sends the boundary query documents to the service, up
+// to maxParallelBatches documents are sent concurrently. It returns the
+// boundary results and the number of retries.
+// executeBoundaryQuery sends the boundary query documents to the service. The
+// raw response data of each document is kept in raw when it is not nil.
+func (q *queryExecution) executeBoundaryQuery(documents []string, serviceURL string, variables []map[string]interface{}, boundaryFieldGetter BoundaryField, maxParallelBatches int, raw [][]byte) ([]interface{}, int, error) {
+	return q.sendBoundaryQuery(documents, variables, boundaryFieldGetter, maxParallelBatches, func(i int, req *Request, out interface{}) (int, error) {
+		if raw == nil {
+			return q.request(serviceURL, req, out)
+		}
+		recorded := &recordedData{value: out}
+		retries, err := q.request(serviceURL, req, recorded)
+		raw[i] = recorded.raw
+		return retries, err
+	})
+}
+
+// sendBoundaryQuery sends the boundary query documents with the request
+// function, called with the index of the document, and returns the boundary
+// results and the number of retries
+func (q *queryExecution) sendBoundaryQuery(documents []string, variables []map[string]interface{}, boundaryFieldGetter BoundaryField, maxParallelBatches int, request func(i int, req *Request, out interface{}) (int, error)) ([]interface{}, int, error) {
+	results := make([][]interface{}, len(documents))
+	var totalRetries int32
+
+	var group errgroup.Group
+	group.SetLimit(maxParallelBatches)
+	for i, document := range documents {
+		i, document := i, document
+		group.Go(func() error {
 			req := NewRequest(document).
-				WithVariables(variables).
+				WithVariables(variables[i]).
 				WithHeaders(GetOutgoingRequestHeadersFromContext(q.ctx)).
 				WithOperationName(q.operationName).
 				WithOperationType(queryObjectName)
 
+			if boundaryFieldGetter.Array {
+				data := struct {
+					Result []interface{} `json:"_result"`
+				}{}
+				retries, err := request(i, req, &data)
+				atomic.AddInt32(&totalRetries, int32(retries))
+				results[i] = data.Result
+				return err
+			}
+
 			partialData := make(map[string]interface{})
-			err := q.graphqlClient.Request(q.ctx, serviceURL, req, &partialData)
+			retries, err := request(i, req, &partialData)
+			atomic.AddInt32(&totalRetries, int32(retries))
 			if err != nil {
-				return nil, err
+				return err
 			}
 			for _, value := range partialData {
-				output = append(output, value)
+				// array boundary fields queried per entity return lists
+				if values, ok := value.([]interface{}); ok {
+					results[i] = append(results[i], values...)
+					continue
+				}
+				results[i] = append(results[i], value)
 			}
-		}
-		return output, nil
+			return nil
+		})
 	}
-
-	if len(documents) != 1 {
-		return nil, errors.New("there should only be a single document for array boundary field lookups")
+	if err := group.Wait(); err != nil {
+		return nil, int(totalRetries), err
 	}
 
-	data := struct {
-		Result []interface{} `json:"_result"`
-	}{}
-
-	req := NewRequest(documents[0]).
-		WithVariables(variables).
-		WithHeaders(GetOutgoingRequestHeadersFromContext(q.ctx)).
-		WithOperationName(q.operationName).
-		WithOperationType(queryObjectName)
-
-	err := q.graphqlClient.Request(q.ctx, serviceURL, req, &data)
-	return data.Result, err
+	output := make([]interface{}, 0)
+	for _, result := range results {
+		output = append(output, result...)
+	}
+	return output, int(totalRetries), nil
 }
 
 func (q *queryExecution) createGQLErrors(step *QueryPlanStep, err error) gqlerror.List {
@@ -322,6 +607,19 @@ func (q *queryExecution) createGQLErrors(step *QueryPlanStep, err error) gqlerro
 			Rule: "",
 		})
 
+	case errors.Is(err, ErrCircuitOpen):
+		outputErrs = append(outputErrs, &gqlerror.Error{
+			Err:       err,
+			Message:   "downstream service unavailable: circuit breaker is open",
+			Path:      path,
+			Locations: locs,
+			Extensions: map[string]interface{}{
+				"selectionSet": formatSelectionSetSingleLine(q.ctx, q.schema, step.SelectionSet),
+				"circuitOpen":  true,
+			},
+			Rule: "",
+		})
+
 	default:
 		outputErrs = append(outputErrs, &gqlerror.Error{
 			Err:       err,
@@ -377,6 +675,18 @@ func trimInsertionPointForNestedBoundaryStep(data []interface{}, childInsertionP
 	return nil, fmt.Errorf("could not find any insertion points inside boundary data")
 }
 
+func equalInsertionPoints(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func executeBrambleStep(queryPlanStep *QueryPlanStep) (*executionResult, error) {
 	result, err := buildTypenameResponseMap(queryPlanStep.SelectionSet, queryPlanStep.ParentType)
 	if err != nil {
@@ -432,6 +742,49 @@ func extractAndDedupeBoundaryIDs(data interface{}, insertionPoint []string, pare
 }
 
 func extractBoundaryIDs(data interface{}, insertionPoint []string, parentType string) ([]string, error) {
+	objects, err := extractBoundaryObjects(data, insertionPoint, parentType)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		id, err := boundaryIDFromMap(obj)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// extractBoundaryRequirements returns the values of the required fields of the
+// boundary objects, by boundary id
+func extractBoundaryRequirements(data interface{}, insertionPoint []string, parentType string) (map[string]map[string]interface{}, error) {
+	objects, err := extractBoundaryObjects(data, insertionPoint, parentType)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]map[string]interface{}, len(objects))
+	for _, obj := range objects {
+		id, err := boundaryIDFromMap(obj)
+		if err != nil {
+			return nil, err
+		}
+		if result[id] == nil {
+			result[id] = map[string]interface{}{}
+		}
+		for k, v := range obj {
+			if strings.HasPrefix(k, requiresAliasPrefix) {
+				result[id][strings.TrimPrefix(k, requiresAliasPrefix)] = v
+			}
+		}
+	}
+	return result, nil
+}
+
+// extractBoundaryObjects returns the objects of the parent type found at the
+// insertion point
+func extractBoundaryObjects(data interface{}, insertionPoint []string, parentType string) ([]map[string]interface{}, error) {
 	ptr := data
 	if ptr == nil {
 		return nil, nil
@@ -445,19 +798,18 @@ func extractBoundaryIDs(data interface{}, insertionPoint []string, parentType st
 			}
 
 			if tpe != parentType {
-				return []string{}, nil
+				return nil, nil
 			}
 
-			id, err := boundaryIDFromMap(ptr)
-			return []string{id}, err
+			return []map[string]interface{}{ptr}, nil
 		case []interface{}:
-			var result []string
+			var result []map[string]interface{}
 			for _, innerPtr := range ptr {
-				ids, err := extractBoundaryIDs(innerPtr, insertionPoint, parentType)
+				objects, err := extractBoundaryObjects(innerPtr, insertionPoint, parentType)
 				if err != nil {
 					return nil, err
 				}
-				result = append(result, ids...)
+				result = append(result, objects...)
 			}
 			return result, nil
 		default:
@@ -466,15 +818,15 @@ func extractBoundaryIDs(data interface{}, insertionPoint []string, parentType st
 	}
 	switch ptr := ptr.(type) {
 	case map[string]interface{}:
-		return extractBoundaryIDs(ptr[insertionPoint[0]], insertionPoint[1:], parentType)
+		return extractBoundaryObjects(ptr[insertionPoint[0]], insertionPoint[1:], parentType)
 	case []interface{}:
-		var result []string
+		var result []map[string]interface{}
 		for _, innerPtr := range ptr {
-			ids, err := extractBoundaryIDs(innerPtr, insertionPoint, parentType)
+			objects, err := extractBoundaryObjects(innerPtr, insertionPoint, parentType)
 			if err != nil {
 				return nil, err
 			}
-			result = append(result, ids...)
+			result = append(result, objects...)
 		}
 		return result, nil
 	default:
@@ -482,37 +834,169 @@ func extractBoundaryIDs(data interface{}, insertionPoint []string, parentType st
 	}
 }
 
-func buildBoundaryQueryDocuments(ctx context.Context, schema *ast.Schema, step *QueryPlanStep, ids []string, parentTypeBoundaryField BoundaryField, batchSize int) ([]string, map[string]interface{}, error) {
-	operation, variables := formatOperation(ctx, step.SelectionSet)
-
+// buildBoundaryQueryDocuments returns the boundary query documents for the ids
+// and their variables. The ids are passed as variables so that the documents
+// only depend on the selection set and the number of ids.
+func buildBoundaryQueryDocuments(ctx context.Context, schema *ast.Schema, step *QueryPlanStep, ids []string, parentTypeBoundaryField BoundaryField, batchSize int) ([]string, []map[string]interface{}, error) {
 	selectionSetQL := formatSelectionSetSingleLine(ctx, schema, step.SelectionSet)
+	if parentTypeBoundaryField.Abstract {
+		selectionSetQL = fmt.Sprintf("{ ... on %s %s }", step.ParentType, selectionSetQL)
+	}
+	idType := "ID"
+	if parentTypeBoundaryField.KeyType != "" {
+		idType = parentTypeBoundaryField.KeyType
+	}
 	if parentTypeBoundaryField.Array {
-		var qids []string
-		for _, id := range ids {
-			qids = append(qids, fmt.Sprintf("%q", id))
+		var keys interface{} = ids
+		if parentTypeBoundaryField.KeyType != "" {
+			values := make([]interface{}, len(ids))
+			for i, id := range ids {
+				key, err := boundaryKeyValue(id, parentTypeBoundaryField)
+				if err != nil {
+					return nil, nil, err
+				}
+				values[i] = key
+			}
+			keys = values
 		}
-		idsQL := fmt.Sprintf("[%s]", strings.Join(qids, ", "))
-		return []string{fmt.Sprintf(`query %s { _result: %s(%s: %s) %s }`, operation, parentTypeBoundaryField.Field, parentTypeBoundaryField.Argument, idsQL, selectionSetQL)}, variables, nil
+		operation, operationVariables := formatOperation(ctx, step.SelectionSet, fmt.Sprintf("$%s: [%s!]!", boundaryIDsVariableName, idType))
+		variables := boundaryQueryVariables(operationVariables)
+		variables[boundaryIDsVariableName] = keys
+		document := fmt.Sprintf(`query %s { _result: %s(%s: $%s) %s }`, operation, parentTypeBoundaryField.Field, parentTypeBoundaryField.Argument, boundaryIDsVariableName, selectionSetQL)
+		return []string{document}, []map[string]interface{}{variables}, nil
 	}
 
 	var (
-		documents      []string
-		selectionIndex int
+		documents []string
+		variables []map[string]interface{}
 	)
 	for _, batch := range batchBy(ids, batchSize) {
-		var selections []string
-		for _, id := range batch {
-			selection := fmt.Sprintf("%s: %s(%s: %q) %s", fmt.Sprintf("_%d", selectionIndex), parentTypeBoundaryField.Field, parentTypeBoundaryField.Argument, id, selectionSetQL)
+		var (
+			selections          []string
+			variableDefinitions []string
+		)
+		for i := range batch {
+			variableName := fmt.Sprintf("%s_%d", boundaryIDVariableName, i)
+			variableDefinitions = append(variableDefinitions, fmt.Sprintf("$%s: %s!", variableName, idType))
+			selection := fmt.Sprintf("_%d: %s(%s: $%s) %s", i, parentTypeBoundaryField.Field, parentTypeBoundaryField.Argument, variableName, selectionSetQL)
 			selections = append(selections, selection)
-			selectionIndex++
 		}
-		document := fmt.Sprintf("query %s { %s }", operation, strings.Join(selections, " "))
-		documents = append(documents, document)
+
+		operation, operationVariables := formatOperation(ctx, step.SelectionSet, variableDefinitions...)
+		batchVariables := boundaryQueryVariables(operationVariables)
+		for i, id := range batch {
+			key, err := boundaryKeyValue(id, parentTypeBoundaryField)
+			if err != nil {
+				return nil, nil, err
+			}
+			batchVariables[fmt.Sprintf("%s_%d", boundaryIDVariableName, i)] = key
+		}
+
+		documents = append(documents, fmt.Sprintf("query %s { %s }", operation, strings.Join(selections, " ")))
+		variables = append(variables, batchVariables)
 	}
 
 	return documents, variables, nil
 }
 
+// buildRequiresBoundaryQueryDocuments returns the boundary query documents for
+// a step selecting fields declared with @requires. The required field values
+// differ per entity, so each entity is queried with its own alias and the
+// required fields are passed as variables.
+func buildRequiresBoundaryQueryDocuments(ctx context.Context, schema *ast.Schema, serviceSchema *ast.Schema, step *QueryPlanStep, ids []string, requirements map[string]map[string]interface{}, parentTypeBoundaryField BoundaryField) ([]string, []map[string]interface{}, error) {
+	idType := "ID"
+	if parentTypeBoundaryField.KeyType != "" {
+		idType = parentTypeBoundaryField.KeyType
+	}
+	parentDef := serviceSchema.Types[step.ParentType]
+
+	var (
+		selections          []string
+		variableDefinitions []string
+	)
+	variables := map[string]interface{}{}
+	for i, id := range ids {
+		variableName := fmt.Sprintf("%s_%d", boundaryIDVariableName, i)
+		variableDefinitions = append(variableDefinitions, fmt.Sprintf("$%s: %s!", variableName, idType))
+		key, err := boundaryKeyValue(id, parentTypeBoundaryField)
+		if err != nil {
+			return nil, nil, err
+		}
+		variables[variableName] = key
+
+		selectionSet := make(ast.SelectionSet, 0, len(step.SelectionSet))
+		for _, selection := range step.SelectionSet {
+			f, ok := selection.(*ast.Field)
+			if !ok {
+				selectionSet = append(selectionSet, selection)
+				continue
+			}
+			def := parentDef.Fields.ForName(f.Name)
+			required := requiredFields(def)
+			if len(required) == 0 {
+				selectionSet = append(selectionSet, selection)
+				continue
+			}
+			newF := *f
+			newF.Arguments = append(ast.ArgumentList{}, f.Arguments...)
+			for _, name := range required {
+				requiredVariableName := fmt.Sprintf("%s%d_%s", requiresAliasPrefix, i, name)
+				if _, ok := variables[requiredVariableName]; !ok {
+					variableDefinitions = append(variableDefinitions, fmt.Sprintf("$%s: %s", requiredVariableName, def.Arguments.ForName(name).Type.String()))
+					variables[requiredVariableName] = requirements[id][name]
+				}
+				newF.Arguments = append(newF.Arguments, &ast.Argument{
+					Name:  name,
+					Value: &ast.Value{Kind: ast.Variable, Raw: requiredVariableName},
+				})
+			}
+			selectionSet = append(selectionSet, &newF)
+		}
+
+		selectionSetQL := formatSelectionSetSingleLine(ctx, schema, selectionSet)
+		if parentTypeBoundaryField.Abstract {
+			selectionSetQL = fmt.Sprintf("{ ... on %s %s }", step.ParentType, selectionSetQL)
+		}
+		argument := "$" + variableName
+		if parentTypeBoundaryField.Array {
+			argument = "[" + argument + "]"
+		}
+		selections = append(selections, fmt.Sprintf("_%d: %s(%s: %s) %s", i, parentTypeBoundaryField.Field, parentTypeBoundaryField.Argument, argument, selectionSetQL))
+	}
+
+	operation, operationVariables := formatOperation(ctx, step.SelectionSet, variableDefinitions...)
+	for k, v := range operationVariables {
+		variables[k] = v
+	}
+	document := fmt.Sprintf("query %s { %s }", operation, strings.Join(selections, " "))
+	return []string{document}, []map[string]interface{}{variables}, nil
+}
+
+// boundaryKeyValue returns the argument value of the boundary field for the
+// id. Ids of boundary types with a declared key are decoded to key objects.
+func boundaryKeyValue(id string, boundaryField BoundaryField) (interface{}, error) {
+	if boundaryField.KeyType == "" {
+		return id, nil
+	}
+	var key map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(id))
+	decoder.UseNumber()
+	if err := decoder.Decode(&key); err != nil {
+		return nil, fmt.Errorf("invalid boundary key %q: %w", id, err)
+	}
+	return key, nil
+}
+
+// boundaryQueryVariables returns a copy of the operation variables, to which
+// the boundary ids are added
+func boundaryQueryVariables(operationVariables map[string]interface{}) map[string]interface{} {
+	variables := make(map[string]interface{}, len(operationVariables)+1)
+	for k, v := range operationVariables {
+		variables[k] = v
+	}
+	return variables
+}
+
 func batchBy(items []string, batchSize int) (batches [][]string) {
 	for batchSize < len(items) {
 		items, batches = items[batchSize:], append(batches, items[0:batchSize:batchSize])
@@ -52,12 +52,62 @@ func TestBuildBoundaryQueryDocuments(t *testing.T) {
 		InsertionPoint: []string{"gizmos", "owner"},
 		Then:           nil,
 	}
-	expected := []string{`query operationName { _result: getOwners(ids: ["1", "2", "3"]) { _bramble_id: id name } }`}
+	expected := []string{`query operationName($_bramble_ids: [ID!]!) { _result: getOwners(ids: $_bramble_ids) { _bramble_id: id name } }`}
 	ctx := testContextWithoutVariables(&ast.OperationDefinition{Name: "operationName"})
 	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 1)
 	require.NoError(t, err)
 	require.Equal(t, expected, docs)
-	require.Equal(t, (map[string]interface{})(nil), vars)
+	require.Equal(t, []map[string]interface{}{{"_bramble_ids": ids}}, vars)
+}
+
+func TestBuildAbstractBoundaryQueryDocuments(t *testing.T) {
+	ddl := `
+		type Owner {
+			id: ID!
+			name: String!
+		}
+
+		type Team {
+			id: ID!
+		}
+
+		union Entity = Owner | Team
+
+		type Query {
+			entities(ids: [ID!]!): [Entity]!
+		}
+	`
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "fixture", Input: ddl})
+	boundaryField := BoundaryField{Field: "entities", Argument: "ids", Array: true, Abstract: true}
+	ids := []string{"1", "2"}
+	selectionSet := []ast.Selection{
+		&ast.Field{
+			Alias:            "_bramble_id",
+			Name:             "id",
+			Definition:       schema.Types["Owner"].Fields.ForName("id"),
+			ObjectDefinition: schema.Types["Owner"],
+		},
+		&ast.Field{
+			Alias:            "name",
+			Name:             "name",
+			Definition:       schema.Types["Owner"].Fields.ForName("name"),
+			ObjectDefinition: schema.Types["Owner"],
+		},
+	}
+	step := &QueryPlanStep{
+		ServiceURL:     "http://example.com:8080",
+		ServiceName:    "test",
+		ParentType:     "Owner",
+		SelectionSet:   selectionSet,
+		InsertionPoint: []string{"gizmos", "owner"},
+		Then:           nil,
+	}
+	expected := []string{`query operationName($_bramble_ids: [ID!]!) { _result: entities(ids: $_bramble_ids) { ... on Owner { _bramble_id: id name } } }`}
+	ctx := testContextWithoutVariables(&ast.OperationDefinition{Name: "operationName"})
+	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 1)
+	require.NoError(t, err)
+	require.Equal(t, expected, docs)
+	require.Equal(t, []map[string]interface{}{{"_bramble_ids": ids}}, vars)
 }
 
 func TestBuildBoundaryQueryDocumentsWithVariables(t *testing.T) {
@@ -93,12 +143,12 @@ func TestBuildBoundaryQueryDocumentsWithVariables(t *testing.T) {
 		InsertionPoint: []string{"gizmos", "owner"},
 		Then:           nil,
 	}
-	expected := []string{`query ($format: String) { _result: getOwners(ids: ["1", "2", "3"]) { _bramble_id: id name(format: $format) } }`}
+	expected := []string{`query ($format: String,$_bramble_ids: [ID!]!) { _result: getOwners(ids: $_bramble_ids) { _bramble_id: id name(format: $format) } }`}
 	ctx := testContextWithVariables(map[string]interface{}{"format": "upper"}, query.Operations[0])
 	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 1)
 	require.NoError(t, err)
 	require.Equal(t, expected, docs)
-	require.Equal(t, map[string]interface{}{"format": "upper"}, vars)
+	require.Equal(t, []map[string]interface{}{{"format": "upper", "_bramble_ids": ids}}, vars)
 }
 
 func TestBuildNonArrayBoundaryQueryDocuments(t *testing.T) {
@@ -144,12 +194,12 @@ func TestBuildNonArrayBoundaryQueryDocuments(t *testing.T) {
 		InsertionPoint: []string{"gizmos", "owner"},
 		Then:           nil,
 	}
-	expected := []string{`query name { _0: getOwner(id: "1") { _bramble_id: id name } _1: getOwner(id: "2") { _bramble_id: id name } _2: getOwner(id: "3") { _bramble_id: id name } }`}
+	expected := []string{`query name($_bramble_id_0: ID!,$_bramble_id_1: ID!,$_bramble_id_2: ID!) { _0: getOwner(id: $_bramble_id_0) { _bramble_id: id name } _1: getOwner(id: $_bramble_id_1) { _bramble_id: id name } _2: getOwner(id: $_bramble_id_2) { _bramble_id: id name } }`}
 	ctx := testContextWithoutVariables(&ast.OperationDefinition{Name: "name"})
 	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 10)
 	require.NoError(t, err)
 	require.Equal(t, expected, docs)
-	require.Equal(t, (map[string]interface{})(nil), vars)
+	require.Equal(t, []map[string]interface{}{{"_bramble_id_0": "1", "_bramble_id_1": "2", "_bramble_id_2": "3"}}, vars)
 }
 
 func TestBuildNonArrayBoundaryQueryDocumentsWithVariables(t *testing.T) {
@@ -186,12 +236,12 @@ func TestBuildNonArrayBoundaryQueryDocumentsWithVariables(t *testing.T) {
 		Then:           nil,
 	}
 
-	expected := []string{`query ($format: String) { _0: getOwner(id: "1") { _bramble_id: id name(format: $format) } _1: getOwner(id: "2") { _bramble_id: id name(format: $format) } _2: getOwner(id: "3") { _bramble_id: id name(format: $format) } }`}
+	expected := []string{`query ($format: String,$_bramble_id_0: ID!,$_bramble_id_1: ID!,$_bramble_id_2: ID!) { _0: getOwner(id: $_bramble_id_0) { _bramble_id: id name(format: $format) } _1: getOwner(id: $_bramble_id_1) { _bramble_id: id name(format: $format) } _2: getOwner(id: $_bramble_id_2) { _bramble_id: id name(format: $format) } }`}
 	ctx := testContextWithVariables(map[string]interface{}{"format": "lower"}, query.Operations[0])
 	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 10)
 	require.NoError(t, err)
 	require.Equal(t, expected, docs)
-	require.Equal(t, map[string]interface{}{"format": "lower"}, vars)
+	require.Equal(t, []map[string]interface{}{{"format": "lower", "_bramble_id_0": "1", "_bramble_id_1": "2", "_bramble_id_2": "3"}}, vars)
 }
 
 func TestBuildBatchedNonArrayBoundaryQueryDocuments(t *testing.T) {
@@ -237,12 +287,51 @@ func TestBuildBatchedNonArrayBoundaryQueryDocuments(t *testing.T) {
 		InsertionPoint: []string{"gizmos", "owner"},
 		Then:           nil,
 	}
-	expected := []string{`query op { _0: getOwner(id: "1") { _bramble_id: id name } _1: getOwner(id: "2") { _bramble_id: id name } }`, `query op { _2: getOwner(id: "3") { _bramble_id: id name } }`}
+	expected := []string{`query op($_bramble_id_0: ID!,$_bramble_id_1: ID!) { _0: getOwner(id: $_bramble_id_0) { _bramble_id: id name } _1: getOwner(id: $_bramble_id_1) { _bramble_id: id name } }`, `query op($_bramble_id_0: ID!) { _0: getOwner(id: $_bramble_id_0) { _bramble_id: id name } }`}
 	ctx := testContextWithoutVariables(&ast.OperationDefinition{Name: "op"})
 	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, ids, boundaryField, 2)
 	require.NoError(t, err)
 	require.Equal(t, expected, docs)
-	require.Equal(t, (map[string]interface{})(nil), vars)
+	require.Equal(t, []map[string]interface{}{{"_bramble_id_0": "1", "_bramble_id_1": "2"}, {"_bramble_id_0": "3"}}, vars)
+}
+
+func TestBuildBoundaryQueryDocumentsWithUnusualIDs(t *testing.T) {
+	ddl := `
+		type Owner {
+			id: ID!
+			name: String!
+		}
+
+		type Query {
+			getOwner(id: ID!): Owner!
+		}
+	`
+	schema := gqlparser.MustLoadSchema(&ast.Source{Name: "fixture", Input: ddl})
+	boundaryField := BoundaryField{Field: "getOwner", Argument: "id", Array: false}
+	step := &QueryPlanStep{
+		ServiceURL: "http://example.com:8080",
+		ParentType: "Owner",
+		SelectionSet: []ast.Selection{
+			&ast.Field{
+				Alias:            "_bramble_id",
+				Name:             "id",
+				Definition:       schema.Types["Owner"].Fields.ForName("id"),
+				ObjectDefinition: schema.Types["Owner"],
+			},
+		},
+	}
+	ctx := testContextWithoutVariables(&ast.OperationDefinition{Name: "op"})
+
+	docs, vars, err := buildBoundaryQueryDocuments(ctx, schema, step, []string{"1"}, boundaryField, 10)
+	require.NoError(t, err)
+	unusualDocs, unusualVars, err := buildBoundaryQueryDocuments(ctx, schema, step, []string{"a\"b\\c\u00e9\n"}, boundaryField, 10)
+	require.NoError(t, err)
+
+	require.Equal(t, docs, unusualDocs, "documents should not depend on the ids")
+	require.Equal(t, []map[string]interface{}{{"_bramble_id_0": "a\"b\\c\u00e9\n"}}, unusualVars)
+	require.Equal(t, []map[string]interface{}{{"_bramble_id_0": "1"}}, vars)
+	_, gqlErr := gqlparser.LoadQuery(schema, docs[0])
+	require.Nil(t, gqlErr)
 }
 
 func TestUnionAndTrimSelectionSet(t *testing.T) {
@@ -99,7 +99,7 @@ func mergeExecutionResultsRec(src interface{}, dst interface{}, insertionPoint [
 					}
 					if srcID == dstID {
 						for k, v := range result {
-							if k == "_bramble_id" {
+							if k == "_bramble_id" || strings.HasPrefix(k, boundaryKeyAliasPrefix) {
 								continue
 							}
 
@@ -150,11 +150,30 @@ func mergeExecutionResultsRec(src interface{}, dst interface{}, insertionPoint [
 	return nil
 }
 
+// boundaryIDFromMap returns the id of a boundary object. Boundary types with a
+// declared key are identified by the JSON encoding of their key fields.
 func boundaryIDFromMap(boundaryMap map[string]interface{}) (string, error) {
 	id, ok := boundaryMap["_bramble_id"].(string)
 	if ok {
 		return id, nil
 	}
+
+	key := make(map[string]interface{})
+	for k, v := range boundaryMap {
+		if strings.HasPrefix(k, boundaryKeyAliasPrefix) {
+			key[strings.TrimPrefix(k, boundaryKeyAliasPrefix)] = v
+		}
+	}
+	if len(key) > 0 {
+		// map keys are sorted when encoded, the id doesn't depend on the
+		// order of the fields
+		b, err := json.Marshal(key)
+		if err != nil {
+			return "", fmt.Errorf("boundaryIDFromMap: %w", err)
+		}
+		return string(b), nil
+	}
+
 	return "", fmt.Errorf(`boundaryIDFromMap: "_bramble_id" not found`)
 }
 
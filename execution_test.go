@@ -10,6 +10,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -1745,12 +1747,13 @@ func TestQueryExecutionMultipleServicesWithArray(t *testing.T) {
 			{
 				schema: schema1,
 				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					var req map[string]string
+					var req Request
 					json.NewDecoder(r.Body).Decode(&req)
-					query := gqlparser.MustLoadQuery(gqlparser.MustLoadSchema(&ast.Source{Input: schema1}), req["query"])
+					query := gqlparser.MustLoadQuery(gqlparser.MustLoadSchema(&ast.Source{Input: schema1}), req.Query)
 					var ids []string
 					for _, s := range query.Operations[0].SelectionSet {
-						ids = append(ids, s.(*ast.Field).Arguments[0].Value.Raw)
+						id, _ := s.(*ast.Field).Arguments[0].Value.Value(req.Variables)
+						ids = append(ids, id.(string))
 					}
 					if query.Operations[0].SelectionSet[0].(*ast.Field).Name == "_movie" {
 						var res string
@@ -1950,12 +1953,13 @@ func TestQueryExecutionMultipleServicesWithNestedArrays(t *testing.T) {
 		{
 			schema: schema1,
 			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var req map[string]string
+				var req Request
 				json.NewDecoder(r.Body).Decode(&req)
-				query := gqlparser.MustLoadQuery(gqlparser.MustLoadSchema(&ast.Source{Input: schema1}), req["query"])
+				query := gqlparser.MustLoadQuery(gqlparser.MustLoadSchema(&ast.Source{Input: schema1}), req.Query)
 				var ids []string
 				for _, s := range query.Operations[0].SelectionSet {
-					ids = append(ids, s.(*ast.Field).Arguments[0].Value.Raw)
+					id, _ := s.(*ast.Field).Arguments[0].Value.Value(req.Variables)
+					ids = append(ids, id.(string))
 				}
 				if query.Operations[0].SelectionSet[0].(*ast.Field).Name == "_movie" {
 					var res string
@@ -2766,118 +2770,1009 @@ func TestMutationExecution(t *testing.T) {
 	f.run(t, es, f.checkSuccess())
 }
 
+func TestMutationExecutionIsSerial(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}
+				type Mutation {
+					createMovie(title: String!): Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					// slow down the first mutation, the others should still wait for it
+					time.Sleep(50 * time.Millisecond)
+					record("createMovie")
+					w.Write([]byte(`{"data": {"createMovie": {"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Jaws"}}}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				type Movie @boundary {
+					id: ID!
+					release: Int
+				}
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}
+				type Mutation {
+					addReview(movieId: ID!, body: String!): Boolean!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var req Request
+					json.NewDecoder(r.Body).Decode(&req)
+					if strings.Contains(req.Query, "addReview") {
+						record("addReview")
+						w.Write([]byte(`{"data": {"addReview": true}}`))
+						return
+					}
+					time.Sleep(50 * time.Millisecond)
+					record("movie")
+					w.Write([]byte(`{"data": {"_0": {"_bramble_id": "1", "_bramble__typename": "Movie", "release": 1975}}}`))
+				}),
+			},
+			{
+				schema: `type Query {
+					ping: Boolean!
+				}
+				type Mutation {
+					notify(message: String!): Boolean!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var req Request
+					json.NewDecoder(r.Body).Decode(&req)
+					record("notify")
+					if strings.Contains(req.Query, "done") {
+						w.Write([]byte(`{"data": {"done": true}}`))
+						return
+					}
+					w.Write([]byte(`{"data": {"notify": true}}`))
+				}),
+			},
+		},
+		query: `mutation {
+			notify(message: "starting")
+			createMovie(title: "Jaws") {
+				title
+				release
+			}
+			addReview(movieId: "1", body: "Great")
+			done: notify(message: "done")
+		}`,
+		expected: `{
+			"notify": true,
+			"createMovie": {
+				"title": "Jaws",
+				"release": 1975
+			},
+			"addReview": true,
+			"done": true
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+
+	assert.Equal(t, []string{"notify", "createMovie", "movie", "addReview", "notify"}, calls)
+}
+
 func TestQueryExecutionWithUnions(t *testing.T) {
 	f := &queryExecutionFixture{
 		services: []testService{
 			{
-				schema: `
-				directive @boundary on OBJECT | FIELD_DEFINITION
-
-				type Dog { name: String! age: Int }
-				type Cat { name: String! age: Int }
-				type Snake { name: String! age: Int }
-				union Animal = Dog | Cat | Snake
+				schema: `
+				directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Dog { name: String! age: Int }
+				type Cat { name: String! age: Int }
+				type Snake { name: String! age: Int }
+				union Animal = Dog | Cat | Snake
+
+				type Person @boundary {
+					id: ID!
+					pet: Animal
+				}
+
+				type Query {
+					animal(id: ID!): Animal
+					person(id: ID!): Person @boundary
+					animals: [Animal]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					if strings.Contains(string(b), "animals") {
+						w.Write([]byte(`{
+							"data": {
+								"foo": [
+									{ "name": "fido", "age": 4, "_bramble__typename": "Dog" },
+									{ "name": "felix", "age": 2, "_bramble__typename": "Cat" },
+									{ "age": 20, "name": "ka", "_bramble__typename": "Snake" }
+								]
+							}
+						}
+						`))
+					} else {
+						w.Write([]byte(`{
+							"data": {
+								"_0": {
+									"_bramble_id": "2",
+									"_bramble__typename": "Person",
+									"pet": {
+										"name": "felix",
+										"age": 2,
+										"_bramble__typename": "Cat"
+									}
+								}
+							}
+						}
+						`))
+					}
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Person @boundary {
+					id: ID!
+					name: String!
+				}
+
+				type Query {
+					person(id: ID!): Person
+				}`,
+
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{
+						"data": {
+							"person": {
+								"_bramble_id": "2",
+								"_bramble__typename": "Person",
+								"name": "Bob"
+							}
+						}
+					}
+					`))
+				}),
+			},
+		},
+		query: `{
+			person(id: "2") {
+				name
+				pet {
+					... on Dog { name, age }
+					... on Cat { name, age }
+					... on Snake { age, name }
+				}
+			}
+			foo: animals {
+				... on Dog { name, age }
+				... on Cat { name, age }
+				... on Snake { age, name }
+			}
+		}`,
+		expected: `{
+			"person": {
+				"name": "Bob",
+				"pet": {
+					"name": "felix",
+					"age": 2
+				}
+			},
+			"foo": [
+				{ "name": "fido", "age": 4 },
+				{ "name": "felix", "age": 2 },
+				{ "age": 20, "name": "ka" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithShareableInterface(t *testing.T) {
+	boundaryHandler := func(typeName, nameField string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			var req Request
+			require.NoError(t, json.Unmarshal(b, &req))
+
+			if !strings.Contains(req.Query, "_0:") {
+				w.Write([]byte(`{"data": {"featured": {"name": "Featured", "director": "Jane", "_bramble_id": "3", "_bramble__typename": "Movie"}}}`))
+				return
+			}
+			id := req.Variables["_bramble_id_0"].(string)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"_0": map[string]interface{}{
+						"_bramble_id":        id,
+						"_bramble__typename": typeName,
+						nameField:            typeName + " " + id,
+					},
+				},
+			})
+		})
+	}
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				directive @shareable on INTERFACE
+
+				interface Product @shareable {
+					name: String!
+				}
+
+				type Book implements Product @boundary {
+					id: ID!
+					name: String!
+				}
+
+				type Query {
+					book(id: ID!): Book @boundary
+				}`,
+				handler: boundaryHandler("Book", "name"),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				directive @shareable on INTERFACE
+
+				interface Product @shareable {
+					name: String!
+				}
+
+				type Movie implements Product @boundary {
+					id: ID!
+					name: String!
+					director: String
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+					featured: Product
+				}`,
+				handler: boundaryHandler("Movie", "name"),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Book @boundary {
+					id: ID!
+				}
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				union SearchResult = Book | Movie
+
+				type Query {
+					search: [SearchResult!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+					assert.NotContains(t, req.Query, "Product", "the service doesn't define the shareable interface")
+
+					w.Write([]byte(`{
+						"data": {
+							"search": [
+								{ "_bramble_id": "1", "_bramble__typename": "Book" },
+								{ "_bramble_id": "2", "_bramble__typename": "Movie" }
+							]
+						}
+					}`))
+				}),
+			},
+		},
+		query: `{
+			search {
+				... on Product { name }
+			}
+			featured {
+				name
+				... on Movie { director }
+			}
+		}`,
+		expected: `{
+			"search": [
+				{ "name": "Book 1" },
+				{ "name": "Movie 2" }
+			],
+			"featured": {
+				"name": "Featured",
+				"director": "Jane"
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithInterfaceFromAnotherService(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String!
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+					featured: Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					t.Error("the query should not be executed")
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				interface Rated {
+					rating: Int!
+				}
+
+				type Movie implements Rated @boundary {
+					id: ID!
+					rating: Int!
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					t.Error("the query should not be executed")
+				}),
+			},
+		},
+		query: `{
+			featured {
+				title
+				... on Rated { rating }
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	for _, service := range es.Services {
+		service.Name = "test"
+	}
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, `input: fragment on "Rated" can't be resolved for type "Movie" by service test, which doesn't define "Rated": interfaces implemented across services must be declared @shareable`, resp.Errors[0].Message)
+	})
+}
+
+func TestQueryExecutionWithUnionBoundaryField(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				type Actor @boundary {
+					id: ID!
+				}
+
+				union SearchResult = Movie | Actor
+
+				type Query {
+					search: [SearchResult!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{
+						"data": {
+							"search": [
+								{ "_bramble_id": "1", "_bramble__typename": "Movie" },
+								{ "_bramble_id": "2", "_bramble__typename": "Actor" }
+							]
+						}
+					}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String!
+				}
+
+				type Actor @boundary {
+					id: ID!
+					name: String!
+				}
+
+				union Entity = Movie | Actor
+
+				type Query {
+					entities(ids: [ID!]!): [Entity]! @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+
+					ids := req.Variables["_bramble_ids"].([]interface{})
+					require.Len(t, ids, 1)
+					if strings.Contains(req.Query, "... on Movie") {
+						assert.Equal(t, "1", ids[0])
+						w.Write([]byte(`{"data": {"_result": [{"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Alien"}]}}`))
+					} else {
+						assert.Contains(t, req.Query, "... on Actor")
+						assert.Equal(t, "2", ids[0])
+						w.Write([]byte(`{"data": {"_result": [{"_bramble_id": "2", "_bramble__typename": "Actor", "name": "Sigourney Weaver"}]}}`))
+					}
+				}),
+			},
+		},
+		query: `{
+			search {
+				... on Movie { title }
+				... on Actor { name }
+			}
+		}`,
+		expected: `{
+			"search": [
+				{ "title": "Alien" },
+				{ "name": "Sigourney Weaver" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithCompositeBoundaryKey(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+				type Product @boundary(key: "tenantId sku") {
+					tenantId: ID!
+					sku: String!
+					name: String!
+				}
+
+				type Query {
+					products: [Product!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					assert.Contains(t, string(b), "_bramble_key_tenantId: tenantId")
+					assert.Contains(t, string(b), "_bramble_key_sku: sku")
+					w.Write([]byte(`{
+						"data": {
+							"products": [
+								{ "_bramble_key_tenantId": "1", "_bramble_key_sku": "A", "_bramble__typename": "Product", "name": "Hammer" },
+								{ "_bramble_key_tenantId": "2", "_bramble_key_sku": "A", "_bramble__typename": "Product", "name": "Saw" },
+								{ "_bramble_key_tenantId": "1", "_bramble_key_sku": "A", "_bramble__typename": "Product", "name": "Hammer" }
+							]
+						}
+					}`))
+				}),
+			},
+			{
+				schema: `directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+				type Product @boundary(key: "tenantId sku") {
+					tenantId: ID!
+					sku: String!
+					price: Float!
+				}
+
+				input ProductKey {
+					tenantId: ID!
+					sku: String!
+				}
+
+				type Query {
+					products(keys: [ProductKey!]!): [Product]! @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+					assert.Contains(t, req.Query, "$_bramble_ids: [ProductKey!]!")
+
+					var result []interface{}
+					for _, key := range req.Variables["_bramble_ids"].([]interface{}) {
+						key := key.(map[string]interface{})
+						result = append(result, map[string]interface{}{
+							"_bramble_key_tenantId": key["tenantId"],
+							"_bramble_key_sku":      key["sku"],
+							"_bramble__typename":    "Product",
+							"price":                 map[string]float64{"1": 9.5, "2": 12}[key["tenantId"].(string)],
+						})
+					}
+					require.Len(t, result, 2, "keys should be deduplicated")
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"_result": result}})
+				}),
+			},
+		},
+		query: `{
+			products {
+				name
+				price
+			}
+		}`,
+		expected: `{
+			"products": [
+				{ "name": "Hammer", "price": 9.5 },
+				{ "name": "Saw", "price": 12 },
+				{ "name": "Hammer", "price": 9.5 }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithPerTypeIdField(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+				type Dog @boundary(key: "uuid") {
+					uuid: ID!
+					name: String!
+				}
+
+				type Query {
+					dogs: [Dog!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					assert.Contains(t, string(b), "_bramble_id: uuid")
+					w.Write([]byte(`{
+						"data": {
+							"dogs": [
+								{ "_bramble_id": "d1", "_bramble__typename": "Dog", "uuid": "d1", "name": "Fido" }
+							]
+						}
+					}`))
+				}),
+			},
+			{
+				schema: `directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+				type Dog @boundary(key: "uuid") {
+					uuid: ID!
+					color: String!
+				}
+
+				type Query {
+					dog(uuid: ID!): Dog @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+					assert.Contains(t, req.Query, "_0: dog(uuid: $_bramble_id_0)")
+					assert.Equal(t, "d1", req.Variables["_bramble_id_0"])
+					w.Write([]byte(`{"data": {"_0": {"_bramble_id": "d1", "_bramble__typename": "Dog", "color": "brown"}}}`))
+				}),
+			},
+		},
+		query: `{
+			dogs {
+				uuid
+				name
+				color
+			}
+		}`,
+		expected: `{
+			"dogs": [
+				{ "uuid": "d1", "name": "Fido", "color": "brown" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithRequiredField(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String!
+				}
+
+				type Query {
+					movies: [Movie!]!
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					assert.Contains(t, string(b), "_bramble_requires_title: title")
+					w.Write([]byte(`{
+						"data": {
+							"movies": [
+								{ "_bramble_id": "1", "_bramble__typename": "Movie", "id": "1", "_bramble_requires_title": "Jaws" },
+								{ "_bramble_id": "2", "_bramble__typename": "Movie", "id": "2", "_bramble_requires_title": "Alien" }
+							]
+						}
+					}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				directive @requires(fields: String!) on FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					summary(title: String!): String! @requires(fields: "title")
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+					assert.Contains(t, req.Query, "summary(title: $_bramble_requires_0_title)")
+					assert.Contains(t, req.Query, "summary(title: $_bramble_requires_1_title)")
+					result := map[string]interface{}{}
+					for i := 0; i < 2; i++ {
+						id := req.Variables[fmt.Sprintf("_bramble_id_%d", i)]
+						title := req.Variables[fmt.Sprintf("_bramble_requires_%d_title", i)]
+						result[fmt.Sprintf("_%d", i)] = map[string]interface{}{
+							"_bramble_id":        id,
+							"_bramble__typename": "Movie",
+							"summary":            fmt.Sprintf("%s (%s)", title, id),
+						}
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": result})
+				}),
+			},
+		},
+		query: `{
+			movies {
+				id
+				summary
+			}
+		}`,
+		expected: `{
+			"movies": [
+				{ "id": "1", "summary": "Jaws (1)" },
+				{ "id": "2", "summary": "Alien (2)" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithRequiredFieldFromAnotherService(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				type Query {
+					movies: [Movie!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{
+						"data": {
+							"movies": [
+								{ "_bramble_id": "1", "_bramble__typename": "Movie", "id": "1" }
+							]
+						}
+					}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String!
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					assert.Contains(t, string(b), "_bramble_requires_title: title")
+					w.Write([]byte(`{"data": {"_0": {"_bramble_id": "1", "_bramble__typename": "Movie", "_bramble_requires_title": "Jaws"}}}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				directive @requires(fields: String!) on FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					summary(title: String!): String! @requires(fields: "title")
+				}
+
+				type Query {
+					movies(ids: [ID!]!): [Movie]! @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					var req Request
+					require.NoError(t, json.Unmarshal(b, &req))
+					assert.Contains(t, req.Query, "_0: movies(ids: [$_bramble_id_0])")
+					assert.Equal(t, "Jaws", req.Variables["_bramble_requires_0_title"])
+					w.Write([]byte(`{"data": {"_0": [{"_bramble_id": "1", "_bramble__typename": "Movie", "summary": "A shark"}]}}`))
+				}),
+			},
+		},
+		query: `{
+			movies {
+				id
+				summary
+			}
+		}`,
+		expected: `{
+			"movies": [
+				{ "id": "1", "summary": "A shark" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithSharedRootField(t *testing.T) {
+	newF := func(owner string) *queryExecutionFixture {
+		handler := func(name string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, owner, name, "query sent to the wrong service")
+				w.Write([]byte(fmt.Sprintf(`{"data": {"products": ["%s"]}}`, name)))
+			})
+		}
+		return &queryExecutionFixture{
+			services: []testService{
+				{
+					schema: `type Query {
+						products: [String!]!
+					}`,
+					handler: handler("old"),
+				},
+				{
+					schema: `directive @owner on FIELD_DEFINITION
+
+					type Query {
+						products: [String!]! @owner
+					}`,
+					handler: handler("new"),
+				},
+			},
+			query: `{
+				products
+			}`,
+			expected: fmt.Sprintf(`{
+				"products": ["%s"]
+			}`, owner),
+		}
+	}
+
+	t.Run("owner directive", func(t *testing.T) {
+		f := newF("new")
+		es := f.setup(t)
+		f.run(t, es, f.checkSuccess())
+	})
+
+	t.Run("config override", func(t *testing.T) {
+		f := newF("old")
+		es := f.setup(t)
+		var services []*Service
+		owners := map[string]string{}
+		for _, service := range es.Services {
+			services = append(services, service)
+			if service.Schema.Query.Fields.ForName("products").Directives.ForName("owner") == nil {
+				owners["Query.products"] = service.ServiceURL
+			}
+		}
+		applyRootFieldOwners(es.Locations, owners, services...)
+		f.run(t, es, f.checkSuccess())
+	})
+}
+
+func TestQueryExecutionWithConfiguredRootFieldOwner(t *testing.T) {
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Query string
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if strings.Contains(req.Query, "service") {
+				schema := `type Service {
+					name: String!
+					version: String!
+					schema: String!
+				}
+
+				type Query {
+					products: [String!]!
+					service: Service!
+				}`
+				encodedSchema, _ := json.Marshal(schema)
+				fmt.Fprintf(w, `{"data": {"service": {"schema": %s, "version": "1.0", "name": %q}}}`, string(encodedSchema), name)
+				return
+			}
+			fmt.Fprintf(w, `{"data": {"products": [%q]}}`, name)
+		}))
+	}
+	oldService, newService := newServer("old"), newServer("new")
+	defer oldService.Close()
+	defer newService.Close()
+
+	es := NewExecutableSchema(nil, 50, NewClient(), NewService(oldService.URL), NewService(newService.URL))
+	require.Error(t, es.UpdateSchema(context.TODO(), true), "a root field declared twice without an owner is invalid")
+
+	es.UpdateRootFieldOwners(map[string]string{"Query.products": newService.URL})
+	require.NoError(t, es.UpdateSchema(context.TODO(), true))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "query { products }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	NewGateway(es, nil).Router(&Config{}).ServeHTTP(rec, req)
+	assert.JSONEq(t, `{"data": {"products": ["new"]}}`, rec.Body.String())
+}
+
+func TestQueryExecutionWithOverriddenField(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
 
-				type Person @boundary {
+				type Movie @boundary {
 					id: ID!
-					pet: Animal
+					title: String!
+					rating: Int
 				}
 
 				type Query {
-					animal(id: ID!): Animal
-					person(id: ID!): Person @boundary
-					animals: [Animal]!
+					movie(id: ID!): Movie!
+					_movie(id: ID!): Movie @boundary
 				}`,
 				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					b, _ := io.ReadAll(r.Body)
-					if strings.Contains(string(b), "animals") {
-						w.Write([]byte(`{
-							"data": {
-								"foo": [
-									{ "name": "fido", "age": 4, "_bramble__typename": "Dog" },
-									{ "name": "felix", "age": 2, "_bramble__typename": "Cat" },
-									{ "age": 20, "name": "ka", "_bramble__typename": "Snake" }
-								]
-							}
-						}
-						`))
-					} else {
-						w.Write([]byte(`{
-							"data": {
-								"_0": {
-									"_bramble_id": "2",
-									"_bramble__typename": "Person",
-									"pet": {
-										"name": "felix",
-										"age": 2,
-										"_bramble__typename": "Cat"
-									}
-								}
-							}
-						}
-						`))
-					}
+					assert.NotContains(t, string(b), "rating", "overridden field sent to the previous service")
+					w.Write([]byte(`{"data": {"movie": {"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Alien"}}}`))
 				}),
 			},
 			{
 				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				directive @override(from: String!) on FIELD_DEFINITION
 
-				type Person @boundary {
+				type Movie @boundary {
 					id: ID!
-					name: String!
+					rating: Int @override(from: "movies")
 				}
 
 				type Query {
-					person(id: ID!): Person
+					_movies(ids: [ID!]!): [Movie]! @boundary
 				}`,
-
 				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					w.Write([]byte(`{
-						"data": {
-							"person": {
-								"_bramble_id": "2",
-								"_bramble__typename": "Person",
-								"name": "Bob"
-							}
-						}
-					}
-					`))
+					w.Write([]byte(`{"data": {"_result": [{"_bramble_id": "1", "_bramble__typename": "Movie", "rating": 8}]}}`))
 				}),
 			},
 		},
 		query: `{
-			person(id: "2") {
-				name
-				pet {
-					... on Dog { name, age }
-					... on Cat { name, age }
-					... on Snake { age, name }
-				}
-			}
-			foo: animals {
-				... on Dog { name, age }
-				... on Cat { name, age }
-				... on Snake { age, name }
+			movie(id: "1") {
+				title
+				rating
 			}
 		}`,
 		expected: `{
-			"person": {
-				"name": "Bob",
-				"pet": {
-					"name": "felix",
-					"age": 2
+			"movie": {
+				"title": "Alien",
+				"rating": 8
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithExecutableDirectives(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @localize(locale: String!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+				type Movie {
+					title: String!
 				}
+
+				type Query {
+					movie: Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					assert.Contains(t, string(b), `title @localize(locale: \"fr\")`)
+					assert.Contains(t, string(b), `... on Movie @localize(locale: \"en\")`)
+					w.Write([]byte(`{"data": {"movie": {"title": "Les Dents de la mer", "original": "Jaws"}}}`))
+				}),
 			},
-			"foo": [
-				{ "name": "fido", "age": 4 },
-				{ "name": "felix", "age": 2 },
-				{ "age": 20, "name": "ka" }
-			]
+			{
+				schema: `type Query {
+					director: String!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"director": "Steven Spielberg"}}`))
+				}),
+			},
+		},
+		query: `{
+			movie {
+				title @localize(locale: "fr")
+				...MovieFragment @localize(locale: "en")
+			}
+		}
+
+		fragment MovieFragment on Movie {
+			original: title
+		}`,
+		expected: `{
+			"movie": {
+				"title": "Les Dents de la mer",
+				"original": "Jaws"
+			}
 		}`,
 	}
 
 	es := f.setup(t)
 	f.run(t, es, f.checkSuccess())
+
+	t.Run("unsupported by the service", func(t *testing.T) {
+		for _, service := range es.Services {
+			service.Name = "test"
+		}
+		f.query = `{
+			director @localize(locale: "fr")
+		}`
+		f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, "input: directive @localize is not supported by service test", resp.Errors[0].Message)
+		})
+	})
 }
 
 func TestQueryExecutionWithNamespaces(t *testing.T) {
@@ -3498,6 +4393,152 @@ type testService struct {
 	handler http.Handler
 }
 
+func TestQueryExecutionWithServiceClients(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movie: String!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Empty(t, r.Header.Get("X-Api-Key"))
+					w.Write([]byte(`{"data": {"movie": "Test title"}}`))
+				}),
+			},
+			{
+				schema: `type Query {
+					report: String!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+					w.Write([]byte(`{"data": {"report": "Test report"}}`))
+				}),
+			},
+		},
+		query: `{
+			movie
+			report
+		}`,
+		expected: `{
+			"movie": "Test title",
+			"report": "Test report"
+		}`,
+	}
+
+	es := f.setup(t)
+	es.ServiceClients = map[string]*GraphQLClient{
+		es.Locations["Query.report"]: NewClient(WithHeaders(http.Header{"X-Api-Key": []string{"secret"}})),
+	}
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithBoundaryBatching(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []Request
+	var inFlight, maxInFlight int32
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+				}
+
+				type Query {
+					movies: [Movie!]!
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movies": [
+						{"_bramble_id": "1", "_bramble__typename": "Movie", "id": "1"},
+						{"_bramble_id": "2", "_bramble__typename": "Movie", "id": "2"},
+						{"_bramble_id": "3", "_bramble__typename": "Movie", "id": "3"},
+						{"_bramble_id": "4", "_bramble__typename": "Movie", "id": "4"},
+						{"_bramble_id": "5", "_bramble__typename": "Movie", "id": "5"}
+					]}}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+
+				type Query {
+					movie(id: ID!): Movie @boundary
+					movies(ids: [ID!]!): [Movie]! @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					current := atomic.AddInt32(&inFlight, 1)
+					defer atomic.AddInt32(&inFlight, -1)
+					for {
+						peak := atomic.LoadInt32(&maxInFlight)
+						if current <= peak || atomic.CompareAndSwapInt32(&maxInFlight, peak, current) {
+							break
+						}
+					}
+					time.Sleep(50 * time.Millisecond)
+
+					var req Request
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					mutex.Lock()
+					requests = append(requests, req)
+					mutex.Unlock()
+
+					data := map[string]interface{}{}
+					for i := 0; req.Variables[fmt.Sprintf("_bramble_id_%d", i)] != nil; i++ {
+						id := req.Variables[fmt.Sprintf("_bramble_id_%d", i)].(string)
+						data[fmt.Sprintf("_%d", i)] = map[string]interface{}{
+							"_bramble_id":        id,
+							"_bramble__typename": "Movie",
+							"title":              "Movie " + id,
+						}
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+				}),
+			},
+		},
+		query: `{
+			movies {
+				id
+				title
+			}
+		}`,
+		expected: `{
+			"movies": [
+				{ "id": "1", "title": "Movie 1" },
+				{ "id": "2", "title": "Movie 2" },
+				{ "id": "3", "title": "Movie 3" },
+				{ "id": "4", "title": "Movie 4" },
+				{ "id": "5", "title": "Movie 5" }
+			]
+		}`,
+	}
+
+	es := f.setup(t)
+	es.BoundaryBatching = map[string]BoundaryBatchConfig{
+		es.Locations["Movie.title"]: {BatchSize: 2, MaxParallelBatches: 2, Lookup: BoundaryLookupSingle},
+	}
+	var services []*Service
+	for _, service := range es.Services {
+		services = append(services, service)
+	}
+	es.BoundaryQueries = buildBoundaryFieldsMap(es.BoundaryBatching, services...)
+
+	f.run(t, es, f.checkSuccess())
+
+	require.Len(t, requests, 3, "5 ids should be queried in batches of 2")
+	for _, req := range requests {
+		assert.Contains(t, req.Query, "movie(id: ")
+		assert.LessOrEqual(t, len(req.Variables), 2)
+	}
+	assert.Equal(t, int32(2), maxInFlight, "batches should be queried concurrently")
+}
+
 type queryExecutionFixture struct {
 	services     []testService
 	variables    map[string]interface{}
@@ -3532,7 +4573,7 @@ func (f *queryExecutionFixture) setup(t *testing.T) *ExecutableSchema {
 
 	es := NewExecutableSchema(nil, 50, nil, services...)
 	es.MergedSchema = merged
-	es.BoundaryQueries = buildBoundaryFieldsMap(services...)
+	es.BoundaryQueries = buildBoundaryFieldsMap(nil, services...)
 	es.Locations = buildFieldURLMap(services...)
 	es.IsBoundary = buildIsBoundaryMap(services...)
 
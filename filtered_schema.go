@@ -0,0 +1,75 @@
+package bramble
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sort"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// filteredSchemaCacheSize is the maximum number of filtered schemas kept in
+// memory. Permissions usually come from a small set of roles so this is
+// rarely reached.
+const filteredSchemaCacheSize = 256
+
+// Fingerprint returns a hash identifying the permissions, two permissions
+// with the same fingerprint allow the same fields.
+func (o *OperationPermissions) Fingerprint() string {
+	h := sha256.New()
+	writeAllowedFieldsFingerprint(h, o.AllowedRootQueryFields)
+	writeAllowedFieldsFingerprint(h, o.AllowedRootMutationFields)
+	writeAllowedFieldsFingerprint(h, o.AllowedRootSubscriptionFields)
+	if o.AllowUntrustedDocuments {
+		h.Write([]byte("untrusted"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeAllowedFieldsFingerprint(w io.Writer, a AllowedFields) {
+	if a.AllowAll {
+		io.WriteString(w, "*;")
+		return
+	}
+	keys := make([]string, 0, len(a.AllowedSubfields))
+	for k := range a.AllowedSubfields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	io.WriteString(w, "{")
+	for _, k := range keys {
+		io.WriteString(w, k)
+		io.WriteString(w, ":")
+		writeAllowedFieldsFingerprint(w, a.AllowedSubfields[k])
+	}
+	io.WriteString(w, "};")
+}
+
+func newFilteredSchemaCache() *lru.Cache[string, *ast.Schema] {
+	cache, err := lru.New[string, *ast.Schema](filteredSchemaCacheSize)
+	if err != nil {
+		// only returned for non-positive sizes
+		panic(err)
+	}
+	return cache
+}
+
+// filteredSchema returns the merged schema filtered by the permissions.
+// Filtered schemas are memoized per permissions fingerprint until the merged
+// schema changes. The schema mutex must be held by the caller.
+func (s *ExecutableSchema) filteredSchema(perms OperationPermissions) *ast.Schema {
+	if s.filteredSchemas == nil {
+		return perms.FilterSchema(s.MergedSchema)
+	}
+
+	fingerprint := perms.Fingerprint()
+	if schema, ok := s.filteredSchemas.Get(fingerprint); ok {
+		return schema
+	}
+
+	schema := perms.FilterSchema(s.MergedSchema)
+	s.filteredSchemas.Add(fingerprint, schema)
+	return schema
+}
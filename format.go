@@ -40,41 +40,45 @@ func formatDocument(ctx context.Context, schema *ast.Schema, operationType strin
 	return strings.ToLower(operationType) + " " + operation + formatSelectionSet(ctx, schema, selectionSet), vars
 }
 
-func formatOperation(ctx context.Context, selection ast.SelectionSet) (string, map[string]interface{}) {
+// formatOperation returns the operation name and the definitions of the
+// variables used by the selection set, followed by the additional variable
+// definitions. It also returns the values of the used variables.
+func formatOperation(ctx context.Context, selection ast.SelectionSet, variableDefinitions ...string) (string, map[string]interface{}) {
 	sb := strings.Builder{}
 
-	if !graphql.HasOperationContext(ctx) {
-		return "", nil
-	}
-	operationCtx := graphql.GetOperationContext(ctx)
-
-	variables := selectionSetVariables(selection)
-	variableNames := map[string]struct{}{}
-	for _, s := range variables {
-		variableNames[s] = struct{}{}
-	}
-
 	var arguments []string
 	usedVariables := map[string]interface{}{}
-	for _, variableDefinition := range operationCtx.Operation.VariableDefinitions {
-		if _, exists := variableNames[variableDefinition.Variable]; !exists {
-			continue
+	if graphql.HasOperationContext(ctx) {
+		operationCtx := graphql.GetOperationContext(ctx)
+
+		variables := selectionSetVariables(selection)
+		variableNames := map[string]struct{}{}
+		for _, s := range variables {
+			variableNames[s] = struct{}{}
 		}
 
-		for varName, varValue := range operationCtx.Variables {
-			if varName == variableDefinition.Variable {
-				usedVariables[varName] = varValue
+		for _, variableDefinition := range operationCtx.Operation.VariableDefinitions {
+			if _, exists := variableNames[variableDefinition.Variable]; !exists {
+				continue
+			}
+
+			for varName, varValue := range operationCtx.Variables {
+				if varName == variableDefinition.Variable {
+					usedVariables[varName] = varValue
+				}
 			}
+
+			argument := fmt.Sprintf("$%s: %s", variableDefinition.Variable, variableDefinition.Type.String())
+			arguments = append(arguments, argument)
 		}
 
-		argument := fmt.Sprintf("$%s: %s", variableDefinition.Variable, variableDefinition.Type.String())
-		arguments = append(arguments, argument)
+		sb.WriteString(operationCtx.OperationName)
 	}
 
-	sb.WriteString(operationCtx.OperationName)
-	if len(arguments) == 0 {
+	if len(arguments) == 0 && len(variableDefinitions) == 0 {
 		return sb.String(), nil
 	}
+	arguments = append(arguments, variableDefinitions...)
 
 	sb.WriteString("(")
 	sb.WriteString(strings.Join(arguments, ","))
@@ -153,16 +157,13 @@ func formatSelection(sb *strings.Builder, schema *ast.Schema, vars map[string]in
 				sb.WriteString(selection.Alias)
 			}
 			formatArgumentList(sb, schema, vars, selection.Arguments)
-			for _, d := range selection.Directives {
-				sb.WriteString(" @")
-				sb.WriteString(d.Name)
-				formatArgumentList(sb, schema, vars, d.Arguments)
-			}
+			formatDirectiveList(sb, schema, vars, selection.Directives)
 			if len(selection.SelectionSet) > 0 {
 				formatSelectionSelectionSet(sb, schema, vars, level, selection.SelectionSet)
 			}
 		case *ast.InlineFragment:
 			fmt.Fprintf(sb, "... on %v", selection.TypeCondition)
+			formatDirectiveList(sb, schema, vars, selection.Directives)
 			formatSelectionSelectionSet(sb, schema, vars, level, selection.SelectionSet)
 		case *ast.FragmentSpread:
 			sb.WriteString("...")
@@ -171,6 +172,14 @@ func formatSelection(sb *strings.Builder, schema *ast.Schema, vars map[string]in
 	}
 }
 
+func formatDirectiveList(sb *strings.Builder, schema *ast.Schema, vars map[string]interface{}, directives ast.DirectiveList) {
+	for _, d := range directives {
+		sb.WriteString(" @")
+		sb.WriteString(d.Name)
+		formatArgumentList(sb, schema, vars, d.Arguments)
+	}
+}
+
 func formatArgumentList(sb *strings.Builder, schema *ast.Schema, vars map[string]interface{}, args ast.ArgumentList) {
 	if len(args) > 0 {
 		sb.WriteString("(")
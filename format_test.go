@@ -351,6 +351,60 @@ func TestFormatSelectionSetInlineFragmentAndDirective(t *testing.T) {
 	assert.Equal(t, formatSelectionSetSingleLine(testContextWithoutVariables(nil), schema, selectionSet), `{ read @skip(if: false) { ... on Gizmo { name weight } } }`)
 }
 
+func TestFormatSelectionSetInlineFragmentWithDirective(t *testing.T) {
+	schema := loadSchema(`
+			directive @localize(locale: String!) on FIELD | INLINE_FRAGMENT
+			interface Named {
+				name: String!
+			}
+			type Gizmo implements Named {
+				name: String!
+			}
+			type Query {
+				read: [Named]
+			}`,
+	)
+	localize := ast.DirectiveList{
+		&ast.Directive{
+			Name: "localize",
+			Arguments: ast.ArgumentList{
+				&ast.Argument{
+					Name: "locale",
+					Value: &ast.Value{
+						Raw:          "fr",
+						Kind:         ast.StringValue,
+						ExpectedType: &ast.Type{NamedType: "String", NonNull: true},
+					},
+				},
+			},
+		},
+	}
+	selectionSet := []ast.Selection{
+		&ast.Field{
+			Alias:            "read",
+			Name:             "read",
+			Definition:       schema.Query.Fields.ForName("read"),
+			ObjectDefinition: schema.Query,
+			SelectionSet: []ast.Selection{
+				&ast.InlineFragment{
+					TypeCondition:    "Gizmo",
+					ObjectDefinition: schema.Types["Gizmo"],
+					Directives:       localize,
+					SelectionSet: []ast.Selection{
+						&ast.Field{
+							Alias:            "name",
+							Name:             "name",
+							Definition:       schema.Types["Gizmo"].Fields.ForName("name"),
+							ObjectDefinition: schema.Types["Gizmo"],
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, `{ read { ... on Gizmo @localize(locale: "fr") { name } } }`, formatSelectionSetSingleLine(testContextWithoutVariables(nil), schema, selectionSet))
+}
+
 func TestFormatEnum(t *testing.T) {
 	schema := loadSchema(`
 		enum Language {
@@ -5,13 +5,17 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	log "github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+const defaultPersistedQueryCacheSize = 1000
+
 // Gateway contains the public and private routers
 type Gateway struct {
 	ExecutableSchema *ExecutableSchema
@@ -42,9 +46,12 @@ func (g *Gateway) UpdateSchemas(interval time.Duration) {
 func (g *Gateway) Router(cfg *Config) http.Handler {
 	mux := http.NewServeMux()
 
-	// Duplicated from `handler.NewDefaultServer` minus
-	// the websocket transport and persisted query extension
+	// Duplicated from `handler.NewDefaultServer`, the persisted query
+	// extension is opt-in
 	gatewayHandler := handler.New(g.ExecutableSchema)
+	gatewayHandler.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
 	gatewayHandler.AddTransport(transport.Options{})
 	gatewayHandler.AddTransport(transport.GET{})
 	gatewayHandler.AddTransport(transport.POST{})
@@ -52,12 +59,22 @@ func (g *Gateway) Router(cfg *Config) http.Handler {
 	if !cfg.DisableIntrospection {
 		gatewayHandler.Use(extension.Introspection{})
 	}
+	if cfg.trustedDocuments != nil {
+		// trusted documents can be sent by hash only
+		gatewayHandler.Use(extension.AutomaticPersistedQuery{
+			Cache: cfg.trustedDocuments,
+		})
+	} else if cfg.PersistedQueries.Enabled {
+		gatewayHandler.Use(extension.AutomaticPersistedQuery{
+			Cache: cfg.persistedQueryCache(),
+		})
+	}
 
 	for _, plugin := range g.plugins {
 		plugin.SetupGatewayHandler(gatewayHandler)
 	}
 
-	mux.Handle("/query", applyMiddleware(otelhttp.NewHandler(gatewayHandler, "/query"), debugMiddleware))
+	mux.Handle("/query", applyMiddleware(otelhttp.NewHandler(gatewayHandler, "/query"), debugMiddleware, cacheControlMiddleware, trafficSplitMiddleware))
 
 	for _, plugin := range g.plugins {
 		plugin.SetupPublicMux(mux)
@@ -72,6 +89,17 @@ func (g *Gateway) Router(cfg *Config) http.Handler {
 	return applyMiddleware(result, monitoringMiddleware)
 }
 
+func (c *Config) persistedQueryCache() graphql.Cache {
+	if c.PersistedQueryCache != nil {
+		return c.PersistedQueryCache
+	}
+	size := c.PersistedQueries.CacheSize
+	if size <= 0 {
+		size = defaultPersistedQueryCacheSize
+	}
+	return lru.New(size)
+}
+
 // PrivateRouter returns the private http handler
 func (g *Gateway) PrivateRouter() http.Handler {
 	mux := http.NewServeMux()
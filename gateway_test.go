@@ -2,14 +2,18 @@ package bramble
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -67,6 +71,139 @@ func TestGatewayQuery(t *testing.T) {
 	assert.JSONEq(t, `{"data": { "test": "Hello" }}`, rec.Body.String())
 }
 
+func TestGatewayPersistedQueries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if strings.Contains(req.Query, "service") {
+			schema := `type Service {
+				name: String!
+				version: String!
+				schema: String!
+			}
+
+			type Query {
+				test: String
+				service: Service!
+			}`
+			encodedSchema, _ := json.Marshal(schema)
+			fmt.Fprintf(w, `{"data": {"service": {"schema": %s, "version": "1.0", "name": "test-service"}}}`, string(encodedSchema))
+		} else {
+			w.Write([]byte(`{ "data": { "test": "Hello" }}`))
+		}
+	}))
+	defer server.Close()
+
+	executableSchema := NewExecutableSchema(nil, 50, NewClient(), NewService(server.URL))
+	require.NoError(t, executableSchema.UpdateSchema(context.TODO(), true))
+
+	query := "query { test }"
+	hash := sha256.Sum256([]byte(query))
+	extensions := fmt.Sprintf(`{"persistedQuery": {"version": 1, "sha256Hash": "%s"}}`, hex.EncodeToString(hash[:]))
+
+	getRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/query?extensions="+url.QueryEscape(extensions), nil)
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		router := NewGateway(executableSchema, nil).Router(&Config{})
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, getRequest())
+		assert.Contains(t, rec.Body.String(), "no operation provided")
+	})
+
+	t.Run("register and use persisted query", func(t *testing.T) {
+		router := NewGateway(executableSchema, nil).Router(&Config{
+			PersistedQueries: PersistedQueriesConfig{Enabled: true},
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, getRequest())
+		assert.JSONEq(t, `{
+			"errors": [{"message": "PersistedQueryNotFound", "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}],
+			"data": null
+		}`, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(fmt.Sprintf(`{"query": %q, "extensions": %s}`, query, extensions)))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		assert.JSONEq(t, `{"data": { "test": "Hello" }}`, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, getRequest())
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data": { "test": "Hello" }}`, rec.Body.String())
+	})
+
+	t.Run("custom cache", func(t *testing.T) {
+		cache := graphql.MapCache{hex.EncodeToString(hash[:]): query}
+		router := NewGateway(executableSchema, nil).Router(&Config{
+			PersistedQueries:    PersistedQueriesConfig{Enabled: true},
+			PersistedQueryCache: cache,
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, getRequest())
+		assert.JSONEq(t, `{"data": { "test": "Hello" }}`, rec.Body.String())
+	})
+}
+
+func TestGatewayTrafficSplitByHeader(t *testing.T) {
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "data": { "test": "canary" }}`))
+	}))
+	defer canary.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if strings.Contains(req.Query, "service") {
+			schema := `type Service {
+				name: String!
+				version: String!
+				schema: String!
+			}
+
+			type Query {
+				test: String
+				service: Service!
+			}`
+			encodedSchema, _ := json.Marshal(schema)
+			fmt.Fprintf(w, `{"data": {"service": {"schema": %s, "version": "1.0", "name": "test-service"}}}`, string(encodedSchema))
+		} else {
+			w.Write([]byte(`{ "data": { "test": "primary" }}`))
+		}
+	}))
+	defer server.Close()
+
+	executableSchema := NewExecutableSchema(nil, 50, NewClient(), NewService(server.URL))
+	require.NoError(t, executableSchema.UpdateSchema(context.TODO(), true))
+	executableSchema.TrafficSplits = []TrafficSplit{{
+		Service: server.URL,
+		Targets: []TrafficSplitTarget{{URL: canary.URL, Header: "X-Canary"}},
+	}}
+	router := NewGateway(executableSchema, nil).Router(&Config{})
+
+	query := func(header http.Header) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query": "query { test }"}`))
+		req.Header = header
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	assert.JSONEq(t, `{"data": {"test": "primary"}}`, query(http.Header{}))
+	assert.JSONEq(t, `{"data": {"test": "canary"}}`, query(http.Header{"X-Canary": []string{"1"}}))
+}
+
 func TestRequestJSONBodyLogging(t *testing.T) {
 	server := NewGateway(NewExecutableSchema(nil, 50, nil), nil).Router(&Config{})
 
@@ -20,6 +20,12 @@ type Service struct {
 	SchemaSource string
 	Schema       *ast.Schema
 	Status       string
+	// CircuitBreaker short-circuits the requests to the service when it is
+	// failing, requests are always sent when nil
+	CircuitBreaker *CircuitBreaker
+	// IDFieldName is the id field name of the service's boundary types
+	// without a declared key, "id" when empty
+	IDFieldName string
 
 	tracer trace.Tracer
 	client *GraphQLClient
@@ -80,7 +86,7 @@ func (s *Service) Update(ctx context.Context) (bool, error) {
 	}
 	s.Schema = schema
 
-	if err := ValidateSchema(s.Schema); err != nil {
+	if err := ValidateSchemaWithOptions(s.Schema, s.schemaOptions()); err != nil {
 		s.Status = fmt.Sprintf("Invalid (%s)", err)
 		return updated, err
 	}
@@ -88,3 +94,13 @@ func (s *Service) Update(ctx context.Context) (bool, error) {
 	s.Status = "OK"
 	return updated, nil
 }
+
+func (s *Service) schemaOptions() SchemaOptions {
+	return SchemaOptions{IDFieldName: s.IDFieldName, ServiceNames: []string{s.Name}}
+}
+
+// idFieldName returns the id field name of the service's boundary types
+// without a declared key
+func (s *Service) idFieldName() string {
+	return s.schemaOptions().idFieldName()
+}
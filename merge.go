@@ -2,6 +2,8 @@ package bramble
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/vektah/gqlparser/v2"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -9,6 +11,11 @@ import (
 
 // MergeSchemas merges the provided schemas together
 func MergeSchemas(schemas ...*ast.Schema) (*ast.Schema, error) {
+	return MergeSchemasWithOptions(SchemaOptions{}, schemas...)
+}
+
+// MergeSchemasWithOptions merges the provided schemas together
+func MergeSchemasWithOptions(opts SchemaOptions, schemas ...*ast.Schema) (*ast.Schema, error) {
 	if len(schemas) < 1 {
 		return nil, fmt.Errorf("no source schemas")
 	}
@@ -35,18 +42,38 @@ func MergeSchemas(schemas ...*ast.Schema) (*ast.Schema, error) {
 		PossibleTypes: make(map[string][]*ast.Definition),
 	}
 
+	// shared root fields and overridden fields are only merged from the
+	// service owning them
+	if len(opts.ServiceNames) == len(schemas) {
+		if err := validateOverrideSources(schemas, opts.ServiceNames); err != nil {
+			return nil, err
+		}
+	}
+	owners, err := fieldOwners(schemas, opts.rootFieldOwnerIndexes())
+	if err != nil {
+		return nil, err
+	}
+	schemas = withoutNonOwnedFields(schemas, owners, opts.ServiceNames)
+
 	merged.Types = schemas[0].Types
 	for _, schema := range schemas[1:] {
-		mergedTypes, err := mergeTypes(merged.Types, schema.Types)
+		mergedTypes, err := mergeTypes(merged.Types, schema.Types, opts.idFieldName())
 		if err != nil {
 			return nil, err
 		}
 		merged.Types = mergedTypes
 	}
 
+	if err := validateRequiredFields(schemas, merged.Types); err != nil {
+		return nil, err
+	}
+
 	merged.Implements = mergeImplements(schemas)
 	merged.PossibleTypes = mergePossibleTypes(schemas, merged.Types)
-	merged.Directives = mergeDirectives(schemas)
+	merged.Directives, err = mergeDirectives(schemas)
+	if err != nil {
+		return nil, err
+	}
 
 	merged.Query = merged.Types[queryObjectName]
 	merged.Mutation = merged.Types[mutationObjectName]
@@ -57,13 +84,23 @@ func MergeSchemas(schemas ...*ast.Schema) (*ast.Schema, error) {
 
 func buildFieldURLMap(services ...*Service) FieldURLMap {
 	result := FieldURLMap{}
-	for _, rs := range services {
+	schemas := make([]*ast.Schema, len(services))
+	for i, rs := range services {
+		schemas[i] = rs.Schema
+	}
+	// the schemas were validated when merged
+	owners, _ := fieldOwners(schemas, nil)
+	for i, rs := range services {
 		for _, t := range rs.Schema.Types {
 			if !t.IsCompositeType() || isGraphQLBuiltinName(t.Name) || t.Name == serviceObjectName {
 				continue
 			}
+			// shareable interfaces are resolved by the service returning them
+			if isShareableInterface(t) {
+				continue
+			}
 			for _, f := range mergeableFields(t) {
-				if isBoundaryObject(t) && isIDField(f) {
+				if isBoundaryObject(t) && isBoundaryKeyField(t, f, rs.idFieldName()) {
 					continue
 				}
 
@@ -77,6 +114,10 @@ func buildFieldURLMap(services ...*Service) FieldURLMap {
 					continue
 				}
 
+				if owner, ok := owners[result.keyFor(t.Name, f.Name)]; ok && owner != i {
+					continue
+				}
+
 				result.RegisterURL(t.Name, f.Name, rs.ServiceURL)
 			}
 		}
@@ -84,6 +125,188 @@ func buildFieldURLMap(services ...*Service) FieldURLMap {
 	return result
 }
 
+// fieldOwners returns the index of the schema owning the fields declared by
+// several schemas, either shared root fields or fields overridden with the
+// @override directive, keyed by field coordinate
+func fieldOwners(schemas []*ast.Schema, rootFieldOwners map[string]int) (map[string]int, error) {
+	owners, err := sharedRootFieldOwners(schemas, rootFieldOwners)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := overriddenFieldOwners(schemas)
+	if err != nil {
+		return nil, err
+	}
+	for coordinate, owner := range overrides {
+		owners[coordinate] = owner
+	}
+	return owners, nil
+}
+
+// overriddenFieldOwners returns the index of the schema overriding each field
+// with the @override directive, keyed by field coordinate
+func overriddenFieldOwners(schemas []*ast.Schema) (map[string]int, error) {
+	owners := map[string]int{}
+	for i, schema := range schemas {
+		for _, t := range schema.Types {
+			if t.Kind != ast.Object || !isBoundaryObject(t) {
+				continue
+			}
+			for _, f := range t.Fields {
+				if overriddenService(f) == "" {
+					continue
+				}
+				coordinate := fmt.Sprintf("%s.%s", t.Name, f.Name)
+				if _, ok := owners[coordinate]; ok {
+					return nil, fmt.Errorf("field %s is overridden by more than one service", coordinate)
+				}
+				owners[coordinate] = i
+			}
+		}
+	}
+	return owners, nil
+}
+
+// overriddenService returns the name of the service overridden by the field
+// with the @override directive, or an empty string
+func overriddenService(f *ast.FieldDefinition) string {
+	d := f.Directives.ForName(overrideDirectiveName)
+	if d == nil {
+		return ""
+	}
+	from := d.Arguments.ForName("from")
+	if from == nil || from.Value == nil {
+		return ""
+	}
+	return from.Value.Raw
+}
+
+// sharedRootFieldOwners returns the index of the schema owning each root field
+// declared by several schemas with the @owner directive or configured in
+// rootFieldOwners, keyed by field coordinate. Shared root fields must be
+// declared identically and have a single owner. A configured owner declaring
+// the field takes precedence over the @owner directive.
+func sharedRootFieldOwners(schemas []*ast.Schema, rootFieldOwners map[string]int) (map[string]int, error) {
+	owners := map[string]int{}
+	for _, rootType := range []string{queryObjectName, mutationObjectName} {
+		declarations := map[string][]int{}
+		var names []string
+		for i, schema := range schemas {
+			t := schema.Types[rootType]
+			if t == nil {
+				continue
+			}
+			for _, f := range mergeableFields(t) {
+				if ft := schema.Types[f.Type.Name()]; ft != nil && isNamespaceObject(ft) {
+					continue
+				}
+				if _, ok := declarations[f.Name]; !ok {
+					names = append(names, f.Name)
+				}
+				declarations[f.Name] = append(declarations[f.Name], i)
+			}
+		}
+
+		for _, name := range names {
+			indexes := declarations[name]
+			coordinate := fmt.Sprintf("%s.%s", rootType, name)
+			owner, configured := rootFieldOwners[coordinate]
+			if !configured || !containsInt(indexes, owner) {
+				owner = -1
+				for _, i := range indexes {
+					if schemas[i].Types[rootType].Fields.ForName(name).Directives.ForName(ownerDirectiveName) == nil {
+						continue
+					}
+					if owner != -1 {
+						return nil, fmt.Errorf("shared root field %s has more than one owner", coordinate)
+					}
+					owner = i
+				}
+			}
+			if owner == -1 || len(indexes) < 2 {
+				continue
+			}
+
+			ownerField := schemas[owner].Types[rootType].Fields.ForName(name)
+			for _, i := range indexes {
+				if f := schemas[i].Types[rootType].Fields.ForName(name); !equalFieldDefinitions(ownerField, f) {
+					return nil, fmt.Errorf("conflicting shared root field %s (definitions must be identical)", coordinate)
+				}
+			}
+			owners[coordinate] = owner
+		}
+	}
+	return owners, nil
+}
+
+// withoutNonOwnedFields returns the schemas without the declarations of the
+// fields owned by another schema. The declaration of an overridden field is
+// only removed from the service named by the @override directive when the
+// service names are known, the other declarations are left to the merge. The
+// schemas are copied rather than modified.
+func withoutNonOwnedFields(schemas []*ast.Schema, owners map[string]int, serviceNames []string) []*ast.Schema {
+	if len(owners) == 0 {
+		return schemas
+	}
+
+	ownedTypes := map[string]bool{}
+	for coordinate := range owners {
+		typeName, _, _ := strings.Cut(coordinate, ".")
+		ownedTypes[typeName] = true
+	}
+
+	result := make([]*ast.Schema, len(schemas))
+	for i, schema := range schemas {
+		newSchema := *schema
+		newSchema.Types = make(map[string]*ast.Definition, len(schema.Types))
+		for name, t := range schema.Types {
+			newSchema.Types[name] = t
+		}
+		for typeName := range ownedTypes {
+			t := schema.Types[typeName]
+			if t == nil {
+				continue
+			}
+			var fields ast.FieldList
+			for _, f := range t.Fields {
+				owner, ok := owners[fmt.Sprintf("%s.%s", typeName, f.Name)]
+				if ok && owner != i && !keepsOverriddenField(schemas[owner].Types[typeName].Fields.ForName(f.Name), serviceNames, i) {
+					continue
+				}
+				fields = append(fields, f)
+			}
+			newT := *t
+			newT.Fields = fields
+			newSchema.Types[typeName] = &newT
+		}
+		result[i] = &newSchema
+	}
+	return result
+}
+
+// keepsOverriddenField returns whether the schema keeps its declaration of the
+// field owned by another schema, which is the case when the owner overrides
+// the field from another service
+func keepsOverriddenField(owned *ast.FieldDefinition, serviceNames []string, i int) bool {
+	from := overriddenService(owned)
+	return from != "" && i < len(serviceNames) && serviceNames[i] != from
+}
+
+// equalFieldDefinitions returns whether both fields have the same type and
+// arguments
+func equalFieldDefinitions(a, b *ast.FieldDefinition) bool {
+	if a.Type.String() != b.Type.String() || len(a.Arguments) != len(b.Arguments) {
+		return false
+	}
+	for _, arg := range a.Arguments {
+		other := b.Arguments.ForName(arg.Name)
+		if other == nil || other.Type.String() != arg.Type.String() {
+			return false
+		}
+	}
+	return true
+}
+
 func buildIsBoundaryMap(services ...*Service) map[string]bool {
 	result := map[string]bool{}
 	for _, rs := range services {
@@ -97,9 +320,21 @@ func buildIsBoundaryMap(services ...*Service) map[string]bool {
 	return result
 }
 
-func buildBoundaryFieldsMap(services ...*Service) BoundaryFieldsMap {
+func buildBoundaryFieldsMap(batching map[string]BoundaryBatchConfig, services ...*Service) BoundaryFieldsMap {
 	result := make(BoundaryFieldsMap)
 	for _, rs := range services {
+		// array boundary fields are preferred unless the service is
+		// configured otherwise
+		singleTypes := make(map[string]bool)
+		if batching[rs.ServiceURL].Lookup == BoundaryLookupSingle {
+			for _, f := range rs.Schema.Query.Fields {
+				if isBoundaryField(f) && f.Type.Elem == nil {
+					singleTypes[f.Type.Name()] = true
+				}
+			}
+		}
+
+		var abstractFields []*ast.FieldDefinition
 		for _, f := range rs.Schema.Query.Fields {
 			if isBoundaryField(f) {
 				typeName := f.Type.Name()
@@ -108,15 +343,52 @@ func buildBoundaryFieldsMap(services ...*Service) BoundaryFieldsMap {
 					typeName = f.Type.Elem.Name()
 					array = true
 				}
+				if array && singleTypes[typeName] {
+					continue
+				}
+				if t := rs.Schema.Types[typeName]; t != nil && t.IsAbstractType() {
+					abstractFields = append(abstractFields, f)
+					continue
+				}
 
-				result.RegisterField(rs.ServiceURL, typeName, f.Name, f.Arguments[0].Name, array)
+				result.register(rs.ServiceURL, typeName, BoundaryField{
+					Field:    f.Name,
+					Argument: f.Arguments[0].Name,
+					Array:    array,
+					KeyType:  boundaryKeyType(f.Arguments[0]),
+				})
+			}
+		}
+
+		// boundary fields returning an interface or a union are used for the
+		// boundary types without a boundary field of their own
+		for _, f := range abstractFields {
+			for _, t := range rs.Schema.PossibleTypes[f.Type.Name()] {
+				if isBoundaryObject(t) {
+					result.register(rs.ServiceURL, t.Name, BoundaryField{
+						Field:    f.Name,
+						Argument: f.Arguments[0].Name,
+						Array:    f.Type.Elem != nil,
+						Abstract: true,
+						KeyType:  boundaryKeyType(f.Arguments[0]),
+					})
+				}
 			}
 		}
 	}
 	return result
 }
 
-func mergeTypes(a, b map[string]*ast.Definition) (map[string]*ast.Definition, error) {
+// boundaryKeyType returns the input object type of a boundary field argument,
+// or an empty string if the field takes ids
+func boundaryKeyType(arg *ast.ArgumentDefinition) string {
+	if name := arg.Type.Name(); name != "ID" {
+		return name
+	}
+	return ""
+}
+
+func mergeTypes(a, b map[string]*ast.Definition, idFieldName string) (map[string]*ast.Definition, error) {
 	result := make(map[string]*ast.Definition)
 	for k, v := range a {
 		if k == nodeInterfaceName || k == serviceObjectName {
@@ -157,6 +429,20 @@ func mergeTypes(a, b map[string]*ast.Definition) (map[string]*ast.Definition, er
 			continue
 		}
 
+		// the scope of the @cacheControl directive is declared by every
+		// service using it
+		if k == cacheControlScopeName && newVB.Kind == ast.Enum {
+			continue
+		}
+
+		if newVB.Kind == ast.Interface && isShareableInterface(&newVB) && isShareableInterface(va) {
+			if err := compareShareableInterfaces(va, &newVB); err != nil {
+				return nil, err
+			}
+			result[k] = va
+			continue
+		}
+
 		if !hasFederationDirectives(&newVB) || !hasFederationDirectives(va) {
 			if k != queryObjectName && k != mutationObjectName {
 				if newVB.Kind == ast.Interface {
@@ -177,7 +463,7 @@ func mergeTypes(a, b map[string]*ast.Definition) (map[string]*ast.Definition, er
 		}
 
 		if isNamespaceObject(&newVB) || k == queryObjectName || k == mutationObjectName || k == subscriptionObjectName {
-			mergedObject, err := mergeNamespaceObjects(a, b, &newVB, va)
+			mergedObject, err := mergeNamespaceObjects(a, b, &newVB, va, idFieldName)
 			if err != nil {
 				return nil, err
 			}
@@ -185,7 +471,7 @@ func mergeTypes(a, b map[string]*ast.Definition) (map[string]*ast.Definition, er
 			continue
 		}
 
-		mergedBoundaryObject, err := mergeBoundaryObjects(&newVB, va)
+		mergedBoundaryObject, err := mergeBoundaryObjects(&newVB, va, idFieldName)
 		if err != nil {
 			return nil, err
 		}
@@ -210,7 +496,7 @@ func mergeImplements(sources []*ast.Schema) map[string][]*ast.Definition {
 	for _, schema := range sources {
 		for typeName, interfaces := range schema.Implements {
 			for _, i := range interfaces {
-				if i.Name != nodeInterfaceName {
+				if i.Name != nodeInterfaceName && ast.DefinitionList(result[typeName]).ForName(i.Name) == nil {
 					result[typeName] = append(result[typeName], i)
 				}
 			}
@@ -219,16 +505,110 @@ func mergeImplements(sources []*ast.Schema) map[string][]*ast.Definition {
 	return result
 }
 
-func mergeDirectives(sources []*ast.Schema) map[string]*ast.DirectiveDefinition {
+func mergeDirectives(sources []*ast.Schema) (map[string]*ast.DirectiveDefinition, error) {
 	result := map[string]*ast.DirectiveDefinition{}
 	for _, schema := range sources {
 		for directive, definition := range schema.Directives {
 			if allowedDirective(directive) {
+				// keep the definition of @boundary declaring the key argument
+				if existing, ok := result[directive]; ok && directive == boundaryDirectiveName && len(existing.Arguments) > len(definition.Arguments) {
+					continue
+				}
 				result[directive] = definition
+				continue
+			}
+			// custom executable directives are forwarded to the services,
+			// every service must define them the same way. The directives
+			// that can't be forwarded are ignored.
+			if !isForwardableDirective(definition) {
+				continue
 			}
+			if existing, ok := result[directive]; ok {
+				if !equalDirectiveDefinitions(existing, definition) {
+					return nil, fmt.Errorf("conflicting directive @%s (definitions must be identical)", directive)
+				}
+				continue
+			}
+			result[directive] = definition
 		}
 	}
-	return result
+	return result, nil
+}
+
+// isCustomExecutableDirective returns whether the directive is defined by the
+// service and can only be used in queries
+func isCustomExecutableDirective(d *ast.DirectiveDefinition) bool {
+	if d.Position != nil && d.Position.Src != nil && d.Position.Src.BuiltIn {
+		return false
+	}
+	if len(d.Locations) == 0 {
+		return false
+	}
+	for _, location := range d.Locations {
+		if !isExecutableDirectiveLocation(location) {
+			return false
+		}
+	}
+	return true
+}
+
+// isForwardableDirective returns whether the directive is a custom executable
+// directive that can be forwarded to the service. Directives are only
+// forwarded on fields and fragments, fragment spreads are sent to the service
+// as inline fragments.
+func isForwardableDirective(d *ast.DirectiveDefinition) bool {
+	if !isCustomExecutableDirective(d) {
+		return false
+	}
+	for _, location := range d.Locations {
+		switch location {
+		case ast.LocationField, ast.LocationInlineFragment:
+		case ast.LocationFragmentSpread:
+			if !containsLocation(d.Locations, ast.LocationInlineFragment) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isExecutableDirectiveLocation(location ast.DirectiveLocation) bool {
+	switch location {
+	case ast.LocationQuery, ast.LocationMutation, ast.LocationSubscription, ast.LocationField,
+		ast.LocationFragmentDefinition, ast.LocationFragmentSpread, ast.LocationInlineFragment, ast.LocationVariableDefinition:
+		return true
+	default:
+		return false
+	}
+}
+
+func equalDirectiveDefinitions(a, b *ast.DirectiveDefinition) bool {
+	if a.IsRepeatable != b.IsRepeatable || len(a.Arguments) != len(b.Arguments) || len(a.Locations) != len(b.Locations) {
+		return false
+	}
+	for _, arg := range a.Arguments {
+		other := b.Arguments.ForName(arg.Name)
+		if other == nil || other.Type.String() != arg.Type.String() || other.DefaultValue.String() != arg.DefaultValue.String() {
+			return false
+		}
+	}
+	for _, location := range a.Locations {
+		if !containsLocation(b.Locations, location) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsLocation(locations []ast.DirectiveLocation, location ast.DirectiveLocation) bool {
+	for _, l := range locations {
+		if l == location {
+			return true
+		}
+	}
+	return false
 }
 
 func mergePossibleTypes(sources []*ast.Schema, mergedTypes map[string]*ast.Definition) map[string][]*ast.Definition {
@@ -252,7 +632,7 @@ func mergePossibleTypes(sources []*ast.Schema, mergedTypes map[string]*ast.Defin
 	return result
 }
 
-func mergeNamespaceObjects(aTypes, bTypes map[string]*ast.Definition, a, b *ast.Definition) (*ast.Definition, error) {
+func mergeNamespaceObjects(aTypes, bTypes map[string]*ast.Definition, a, b *ast.Definition, idFieldName string) (*ast.Definition, error) {
 	var fields ast.FieldList
 	for _, f := range a.Fields {
 		if isQueryType(a) && (isNodeField(f) || isServiceField(f)) {
@@ -264,7 +644,7 @@ func mergeNamespaceObjects(aTypes, bTypes map[string]*ast.Definition, a, b *ast.
 		if rf := fields.ForName(f.Name); rf != nil {
 			if f.Type.String() == rf.Type.String() && f.Type.NonNull &&
 				isNamespaceObject(aTypes[rf.Type.Name()]) && isNamespaceObject(bTypes[f.Type.Name()]) &&
-				!hasIDField(aTypes[rf.Type.Name()]) && !hasIDField(bTypes[f.Type.Name()]) &&
+				!hasIDField(aTypes[rf.Type.Name()], idFieldName) && !hasIDField(bTypes[f.Type.Name()], idFieldName) &&
 				len(f.Arguments) == 0 && len(rf.Arguments) == 0 {
 				continue
 			}
@@ -284,8 +664,13 @@ func mergeNamespaceObjects(aTypes, bTypes map[string]*ast.Definition, a, b *ast.
 	}, nil
 }
 
-func mergeBoundaryObjects(a, b *ast.Definition) (*ast.Definition, error) {
-	mergedFields, err := mergeBoundaryObjectFields(a, b)
+func mergeBoundaryObjects(a, b *ast.Definition, idFieldName string) (*ast.Definition, error) {
+	keyA, keyB := boundaryKey(a, idFieldName), boundaryKey(b, idFieldName)
+	if !equalBoundaryKeys(keyA, keyB) {
+		return nil, fmt.Errorf("conflicting boundary keys for %q: %q and %q", a.Name, strings.Join(keyA, " "), strings.Join(keyB, " "))
+	}
+
+	mergedFields, err := mergeBoundaryObjectFields(a, b, idFieldName)
 	if err != nil {
 		return nil, err
 	}
@@ -294,13 +679,55 @@ func mergeBoundaryObjects(a, b *ast.Definition) (*ast.Definition, error) {
 		Kind:        ast.Object,
 		Description: mergeDescriptions(a, b),
 		Name:        a.Name,
-		Directives:  a.Directives.ForNames(boundaryDirectiveName),
-		Interfaces:  append(a.Interfaces, b.Interfaces...),
+		Directives:  append(a.Directives.ForNames(boundaryDirectiveName), mergeCacheControlDirectives(a, b)...),
+		Interfaces:  mergeInterfaceNames(a.Interfaces, b.Interfaces),
 		Fields:      mergedFields,
 	}, nil
 }
 
-func mergeBoundaryObjectFields(a, b *ast.Definition) (ast.FieldList, error) {
+func equalBoundaryKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeInterfaceNames returns the interfaces of both objects, shareable
+// interfaces can be implemented in multiple services
+func mergeInterfaceNames(a, b []string) []string {
+	result := append([]string{}, a...)
+	for _, i := range b {
+		if !containsString(result, i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// compareShareableInterfaces returns an error if the shareable interface
+// definitions differ
+func compareShareableInterfaces(a, b *ast.Definition) error {
+	if len(a.Fields) != len(b.Fields) {
+		return fmt.Errorf("conflicting shareable interface: %s (definitions must be identical)", a.Name)
+	}
+	for _, fa := range a.Fields {
+		fb := b.Fields.ForName(fa.Name)
+		if fb == nil || !equalFieldDefinitions(fa, fb) {
+			return fmt.Errorf("conflicting shareable interface: %s (definitions must be identical)", a.Name)
+		}
+	}
+	return nil
+}
+
+func mergeBoundaryObjectFields(a, b *ast.Definition, idFieldName string) (ast.FieldList, error) {
 	var result ast.FieldList
 	for _, f := range a.Fields {
 		if isQueryType(a) && (isNodeField(f) || isServiceField(f)) {
@@ -309,7 +736,7 @@ func mergeBoundaryObjectFields(a, b *ast.Definition) (ast.FieldList, error) {
 		result = append(result, f)
 	}
 	for _, f := range mergeableFields(b) {
-		if isIDField(f) {
+		if isIDField(f, idFieldName) || isBoundaryKeyField(b, f, idFieldName) {
 			continue
 		}
 		if rf := result.ForName(f.Name); rf != nil {
@@ -375,25 +802,125 @@ func cleanFields(fields ast.FieldList) ast.FieldList {
 			continue
 		}
 
-		f.Directives = cleanDirectives(f.Directives)
-		res = append(res, f)
+		// the service schema is used for planning, the field is copied
+		// rather than modified
+		newF := *f
+		newF.Directives = cleanDirectives(f.Directives)
+		newF.Arguments = cleanRequiredArguments(f)
+		res = append(res, &newF)
+	}
+
+	return res
+}
+
+// cleanRequiredArguments returns the arguments of the field, without the
+// arguments filled by the gateway with the required fields
+func cleanRequiredArguments(f *ast.FieldDefinition) ast.ArgumentDefinitionList {
+	required := requiredFields(f)
+	if len(required) == 0 {
+		return f.Arguments
 	}
 
+	var res ast.ArgumentDefinitionList
+	for _, arg := range f.Arguments {
+		if !containsString(required, arg.Name) {
+			res = append(res, arg)
+		}
+	}
 	return res
 }
 
+// validateRequiredFields checks that the fields required with the @requires
+// directive are scalar or enum fields of the merged type, and that the
+// arguments filled with their values accept them
+func validateRequiredFields(schemas []*ast.Schema, types map[string]*ast.Definition) error {
+	for _, schema := range schemas {
+		for _, t := range schema.Types {
+			for _, f := range t.Fields {
+				for _, name := range requiredFields(f) {
+					var def *ast.FieldDefinition
+					if mergedType := types[t.Name]; mergedType != nil {
+						def = mergedType.Fields.ForName(name)
+					}
+					if def == nil {
+						return fmt.Errorf("@requires directive on %s.%s: required field %q does not exist", t.Name, f.Name, name)
+					}
+					if fieldType := types[def.Type.Name()]; fieldType == nil || (fieldType.Kind != ast.Scalar && fieldType.Kind != ast.Enum) {
+						return fmt.Errorf("@requires directive on %s.%s: required field %q must be a scalar or enum field", t.Name, f.Name, name)
+					}
+					arg := f.Arguments.ForName(name)
+					if arg == nil || !acceptsRequiredValue(arg.Type, def.Type) {
+						return fmt.Errorf("@requires directive on %s.%s: argument %q should have the type of the required field, %s", t.Name, f.Name, name, def.Type.String())
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// acceptsRequiredValue returns whether an argument of type arg accepts the
+// values of a field of type field
+func acceptsRequiredValue(arg, field *ast.Type) bool {
+	if arg.NonNull && !field.NonNull {
+		return false
+	}
+	if (arg.Elem == nil) != (field.Elem == nil) {
+		return false
+	}
+	if arg.Elem != nil {
+		return acceptsRequiredValue(arg.Elem, field.Elem)
+	}
+	return arg.NamedType == field.NamedType
+}
+
+// requiredFields returns the fields of the parent type declared as required by
+// the field with the @requires directive
+func requiredFields(f *ast.FieldDefinition) []string {
+	if f == nil {
+		return nil
+	}
+	d := f.Directives.ForName(requiresDirectiveName)
+	if d == nil {
+		return nil
+	}
+	arg := d.Arguments.ForName("fields")
+	if arg == nil || arg.Value == nil {
+		return nil
+	}
+	return strings.Fields(arg.Value.Raw)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func allowedDirective(name string) bool {
 	switch name {
-	case boundaryDirectiveName, namespaceDirectiveName, "skip", "include", "deprecated":
+	case boundaryDirectiveName, namespaceDirectiveName, shareableDirectiveName, costDirectiveName, cacheControlDirectiveName, "skip", "include", "deprecated":
 		return true
 	default:
 		return false
 	}
 }
 
-func hasIDField(t *ast.Definition) bool {
+func hasIDField(t *ast.Definition, idFieldName string) bool {
 	for _, f := range t.Fields {
-		if isIDField(f) {
+		if isIDField(f, idFieldName) {
 			return true
 		}
 	}
@@ -401,18 +928,19 @@ func hasIDField(t *ast.Definition) bool {
 	return false
 }
 
+// isNodeField returns whether the field is the node root field, the name of
+// its argument is checked by the schema validation
 func isNodeField(f *ast.FieldDefinition) bool {
 	if f.Name != nodeRootFieldName || len(f.Arguments) != 1 {
 		return false
 	}
 	arg := f.Arguments[0]
-	return arg.Name == IdFieldName &&
-		isIDType(arg.Type) &&
+	return isIDType(arg.Type) &&
 		isNullableTypeNamed(f.Type, nodeInterfaceName)
 }
 
-func isIDField(f *ast.FieldDefinition) bool {
-	return f.Name == IdFieldName && len(f.Arguments) == 0 && isIDType(f.Type)
+func isIDField(f *ast.FieldDefinition, idFieldName string) bool {
+	return f.Name == idFieldName && len(f.Arguments) == 0 && isIDType(f.Type)
 }
 
 func isServiceField(f *ast.FieldDefinition) bool {
@@ -429,6 +957,74 @@ func isBoundaryObject(a *ast.Definition) bool {
 	return a.Directives.ForName(boundaryDirectiveName) != nil
 }
 
+// boundaryKey returns the key fields of a boundary type, declared with the key
+// argument of the @boundary directive. Types without a declared key are keyed
+// by their id field.
+func boundaryKey(t *ast.Definition, idFieldName string) []string {
+	if hasBoundaryKey(t) {
+		return declaredBoundaryKey(t)
+	}
+	return []string{idFieldName}
+}
+
+// declaredBoundaryKey returns the key fields declared with the key argument of
+// the @boundary directive, or nil
+func declaredBoundaryKey(t *ast.Definition) []string {
+	if t == nil {
+		return nil
+	}
+	d := t.Directives.ForName(boundaryDirectiveName)
+	if d == nil {
+		return nil
+	}
+	arg := d.Arguments.ForName(boundaryKeyArgumentName)
+	if arg == nil || arg.Value == nil {
+		return nil
+	}
+	return strings.Fields(arg.Value.Raw)
+}
+
+// hasBoundaryKey returns whether the boundary type declares its key fields
+func hasBoundaryKey(t *ast.Definition) bool {
+	return len(declaredBoundaryKey(t)) > 0
+}
+
+// boundaryIDFieldName returns the name of the id field of a boundary type, a
+// declared key made of a single ID! field is the id field of the type. An
+// empty name is returned for composite keys.
+func boundaryIDFieldName(t *ast.Definition, idFieldName string) string {
+	if !hasBoundaryKey(t) {
+		return idFieldName
+	}
+	if hasCompositeBoundaryKey(t) {
+		return ""
+	}
+	return declaredBoundaryKey(t)[0]
+}
+
+// hasCompositeBoundaryKey returns whether the boundary type is looked up with
+// its key fields rather than with ids
+func hasCompositeBoundaryKey(t *ast.Definition) bool {
+	if !hasBoundaryKey(t) {
+		return false
+	}
+	key := declaredBoundaryKey(t)
+	if len(key) > 1 {
+		return true
+	}
+	f := t.Fields.ForName(key[0])
+	return f == nil || !isIDType(f.Type)
+}
+
+// isBoundaryKeyField returns whether the field is part of the key of the
+// boundary type
+func isBoundaryKeyField(t *ast.Definition, f *ast.FieldDefinition, idFieldName string) bool {
+	if !hasBoundaryKey(t) {
+		return isIDField(f, idFieldName)
+	}
+	return containsString(declaredBoundaryKey(t), f.Name)
+}
+
 func isBoundaryField(f *ast.FieldDefinition) bool {
 	return f.Directives.ForName(boundaryDirectiveName) != nil
 }
@@ -437,6 +1033,10 @@ func isNamespaceObject(a *ast.Definition) bool {
 	return a.Directives.ForName(namespaceDirectiveName) != nil
 }
 
+func isShareableInterface(a *ast.Definition) bool {
+	return a != nil && a.Kind == ast.Interface && a.Directives.ForName(shareableDirectiveName) != nil
+}
+
 func hasFederationDirectives(o *ast.Definition) bool {
 	return isBoundaryObject(o) || isNamespaceObject(o)
 }
@@ -14,6 +14,7 @@ type MergeTestFixture struct {
 	Input2   string
 	Expected string
 	Error    string
+	Options  SchemaOptions
 }
 
 type BuildFieldURLMapFixture struct {
@@ -33,7 +34,8 @@ func (f MergeTestFixture) CheckSuccess(t *testing.T) {
 	if f.Input2 != "" {
 		schemas = append(schemas, loadSchema(f.Input2))
 	}
-	actual := mustMergeSchemas(t, schemas...)
+	actual, err := MergeSchemasWithOptions(f.Options, schemas...)
+	require.NoError(t, err)
 
 	// If resulting Query type is empty, remove it from schema to avoid
 	// generating an invalid schema when formatting (empty Query type: `type Query {}`)
@@ -55,7 +57,7 @@ func (f MergeTestFixture) CheckError(t *testing.T) {
 	if f.Input2 != "" {
 		schemas = append(schemas, loadSchema(f.Input2))
 	}
-	_, err := MergeSchemas(schemas...)
+	_, err := MergeSchemasWithOptions(f.Options, schemas...)
 	assert.Error(t, err)
 	assert.Equal(t, f.Error, err.Error())
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMergeSingleSchema(t *testing.T) {
@@ -140,6 +141,404 @@ func TestMergeTwoSchemasWithCollidingInterface(t *testing.T) {
 	fixture.CheckError(t)
 }
 
+func TestMergeTwoSchemasWithShareableInterface(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT
+			directive @shareable on INTERFACE
+
+			interface Product @shareable {
+				name(locale: String): String!
+			}
+
+			type Book implements Product @boundary {
+				id: ID!
+				name(locale: String): String!
+			}
+
+			type Query {
+				book(id: ID!): Book!
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT
+			directive @shareable on INTERFACE
+
+			interface Product @shareable {
+				name(locale: String): String!
+			}
+
+			type Movie implements Product @boundary {
+				id: ID!
+				name(locale: String): String!
+			}
+
+			type Query {
+				movie(id: ID!): Movie!
+			}
+		`,
+		Expected: `
+			directive @boundary on OBJECT
+			directive @shareable on INTERFACE
+
+			interface Product @shareable {
+				name(locale: String): String!
+			}
+
+			type Book implements Product @boundary {
+				id: ID!
+				name(locale: String): String!
+			}
+
+			type Movie implements Product @boundary {
+				id: ID!
+				name(locale: String): String!
+			}
+
+			type Query {
+				movie(id: ID!): Movie!
+				book(id: ID!): Book!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeTwoSchemasWithConflictingShareableInterface(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @shareable on INTERFACE
+
+			interface Product @shareable {
+				name: String!
+			}
+
+			type Query {
+				product: Product
+			}
+		`,
+		Input2: `
+			directive @shareable on INTERFACE
+
+			interface Product @shareable {
+				name: String
+			}
+
+			type Query {
+				products: [Product!]!
+			}
+		`,
+		Error: "conflicting shareable interface: Product (definitions must be identical)",
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeSharedRootField(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Product @boundary {
+				id: ID!
+				name: String!
+			}
+			type Query {
+				"old products"
+				products(first: Int): [Product!]!
+				product(id: ID!): Product @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @owner on FIELD_DEFINITION
+			type Product @boundary {
+				id: ID!
+				price: Float!
+			}
+			type Query {
+				"new products"
+				products(first: Int): [Product!]! @owner
+				product(id: ID!): Product @boundary
+			}
+		`,
+		Expected: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Product @boundary {
+				id: ID!
+				price: Float!
+				name: String!
+			}
+			type Query {
+				"new products"
+				products(first: Int): [Product!]!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeSharedRootFieldWithConfiguredOwner(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			type Query {
+				"old products"
+				products(first: Int): [String!]!
+			}
+		`,
+		Input2: `
+			type Query {
+				"new products"
+				products(first: Int): [String!]!
+			}
+		`,
+		Expected: `
+			type Query {
+				"old products"
+				products(first: Int): [String!]!
+			}
+		`,
+		Options: SchemaOptions{
+			ServiceNames:    []string{"old", "new"},
+			RootFieldOwners: map[string]string{"Query.products": "old"},
+		},
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeSharedRootFieldWithoutOwner(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			type Query {
+				products(first: Int): [String!]!
+			}
+		`,
+		Input2: `
+			type Query {
+				products(first: Int): [String!]!
+			}
+		`,
+		Error: "overlapping namespace fields Query : products",
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeSharedRootFieldWithConflictingDefinitions(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			type Query {
+				products: [String!]!
+			}
+		`,
+		Input2: `
+			directive @owner on FIELD_DEFINITION
+			type Query {
+				products(first: Int): [String!]! @owner
+			}
+		`,
+		Error: "conflicting shared root field Query.products (definitions must be identical)",
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeSharedRootFieldWithMultipleOwners(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @owner on FIELD_DEFINITION
+			type Query {
+				products: [String!]! @owner
+			}
+		`,
+		Input2: `
+			directive @owner on FIELD_DEFINITION
+			type Query {
+				products: [String!]! @owner
+			}
+		`,
+		Error: "shared root field Query.products has more than one owner",
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeOverriddenField(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				title: String!
+				rating: Int
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @override(from: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				"rating out of 10"
+				rating: Float @override(from: "movies")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Expected: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				"rating out of 10"
+				rating: Float
+				title: String!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeOverriddenFieldDeclaredByThreeServices(t *testing.T) {
+	movies := loadSchema(`
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		type Movie @boundary {
+			id: ID!
+			title: String!
+			rating: Int
+		}
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+	`)
+	reviews := loadSchema(`
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		type Movie @boundary {
+			id: ID!
+			rating: Int
+		}
+		type Query {
+			_movie(id: ID!): Movie @boundary
+		}
+	`)
+	ratings := loadSchema(`
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @override(from: String!) on FIELD_DEFINITION
+		type Movie @boundary {
+			id: ID!
+			rating: Float @override(from: "movies")
+		}
+		type Query {
+			movies(ids: [ID!]!): [Movie]! @boundary
+		}
+	`)
+	opts := SchemaOptions{ServiceNames: []string{"movies", "reviews", "ratings"}}
+
+	_, err := MergeSchemasWithOptions(opts, movies, reviews, ratings)
+	assert.EqualError(t, err, "overlapping fields Movie : rating")
+
+	opts.ServiceNames = []string{"movies", "ratings"}
+	merged, err := MergeSchemasWithOptions(opts, movies, ratings)
+	require.NoError(t, err)
+	assert.Equal(t, "Float", merged.Types["Movie"].Fields.ForName("rating").Type.String())
+}
+
+func TestMergeFieldOverriddenTwice(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @override(from: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				rating: Int @override(from: "movies")
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @override(from: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				rating: Int @override(from: "movies")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Error: "field Movie.rating is overridden by more than one service",
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeBoundaryTypesWithConflictingKeys(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary(key: String) on OBJECT
+			type Product @boundary(key: "tenantId sku") {
+				tenantId: ID!
+				sku: String!
+				name: String
+			}
+			type Query {
+				products: [Product!]!
+			}
+		`,
+		Input2: `
+			directive @boundary(key: String) on OBJECT
+			type Product @boundary(key: "sku") {
+				tenantId: ID!
+				sku: String!
+				price: Float
+			}
+			type Query {
+				product: Product
+			}
+		`,
+		Error: `conflicting boundary keys for "Product": "sku" and "tenantId sku"`,
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeBoundaryTypesWithCompositeKey(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary(key: String) on OBJECT
+			type Product @boundary(key: "tenantId sku") {
+				tenantId: ID!
+				sku: String!
+				name: String
+			}
+			type Query {
+				products: [Product!]!
+			}
+		`,
+		Input2: `
+			directive @boundary(key: String) on OBJECT
+			type Product @boundary(key: "sku tenantId") {
+				tenantId: ID!
+				sku: String!
+				price: Float
+			}
+			type Query {
+				product: Product
+			}
+		`,
+		Expected: `
+			directive @boundary(key: String) on OBJECT
+			type Product @boundary(key: "sku tenantId") {
+				tenantId: ID!
+				sku: String!
+				price: Float
+				name: String
+			}
+			type Query {
+				product: Product
+				products: [Product!]!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
 func TestMergeTwoSchemasWithBoundaryTypes(t *testing.T) {
 	fixture := MergeTestFixture{
 		Input1: `
@@ -885,8 +1284,8 @@ func TestMergeRemovesCustomDirectives(t *testing.T) {
 }
 
 func TestMergeWithAlternateId(t *testing.T) {
-	IdFieldName = "gid"
 	fixture := MergeTestFixture{
+		Options: SchemaOptions{IDFieldName: "gid"},
 		Input1: `
 			directive @boundary on OBJECT | FIELD_DEFINITION
 			type Dog @boundary {
@@ -921,7 +1320,177 @@ func TestMergeWithAlternateId(t *testing.T) {
 		`,
 	}
 	fixture.CheckSuccess(t)
-	IdFieldName = "id" // reset!
+}
+
+func TestMergeWithPerTypeIdField(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+			type Dog @boundary(key: "gid") {
+				gid: ID!
+				name: String
+			}
+			type Cat @boundary {
+				id: ID!
+				name: String
+			}
+			type Query {
+				dog(gid: ID!): Dog @boundary
+				cat(id: ID!): Cat @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+			type Dog @boundary(key: "gid") {
+				gid: ID!
+				color: String
+			}
+			type Cat @boundary {
+				id: ID!
+				color: String
+			}
+			type Query {
+				dogs(gids: [ID!]!): [Dog]! @boundary
+				cats(ids: [ID!]!): [Cat]! @boundary
+			}
+		`,
+		Expected: `
+			directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+			type Dog @boundary(key: "gid") {
+				gid: ID!
+				color: String
+				name: String
+			}
+			type Cat @boundary {
+				id: ID!
+				color: String
+				name: String
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeHidesRequiredFieldArguments(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				title: String!
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @requires(fields: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				summary(title: String!, short: Boolean): String! @requires(fields: "title")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Expected: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				summary(short: Boolean): String!
+				title: String!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeRequiredFieldMustBeLeaf(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Dimensions {
+				width: Int!
+				height: Int!
+			}
+			type Movie @boundary {
+				id: ID!
+				dimensions: Dimensions!
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @requires(fields: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				aspectRatio(dimensions: String!): Float! @requires(fields: "dimensions")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Error: `@requires directive on Movie.aspectRatio: required field "dimensions" must be a scalar or enum field`,
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeRequiredFieldArgumentType(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				title: String
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @requires(fields: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				summary(title: String!): String! @requires(fields: "title")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Error: `@requires directive on Movie.summary: argument "title" should have the type of the required field, String`,
+	}
+	fixture.CheckError(t)
+}
+
+func TestMergeRequiredFieldMustExist(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+			}
+			type Query {
+				movie(id: ID!): Movie @boundary
+			}
+		`,
+		Input2: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @requires(fields: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				summary(title: String!): String! @requires(fields: "title")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`,
+		Error: `@requires directive on Movie.summary: required field "title" does not exist`,
+	}
+	fixture.CheckError(t)
 }
 
 func TestMergePossibleTypes(t *testing.T) {
@@ -999,3 +1568,53 @@ func TestMergePossibleTypes(t *testing.T) {
 	}
 	fixture.CheckSuccess(t)
 }
+
+func TestMergeExecutableDirectives(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @lowercase on FIELD
+			directive @localize(locale: String!) on FIELD | INLINE_FRAGMENT
+			directive @internal on FIELD_DEFINITION
+			directive @trace on QUERY
+			directive @translate on FRAGMENT_SPREAD
+			type Query {
+				title: String! @internal
+			}
+		`,
+		Input2: `
+			directive @localize(locale: String!) on INLINE_FRAGMENT | FIELD
+			directive @trace(sampled: Boolean) on QUERY | FIELD
+			type Query {
+				description: String!
+			}
+		`,
+		Expected: `
+			directive @lowercase on FIELD
+			directive @localize(locale: String!) on FIELD | INLINE_FRAGMENT
+			type Query {
+				description: String!
+				title: String!
+			}
+		`,
+	}
+	fixture.CheckSuccess(t)
+}
+
+func TestMergeConflictingExecutableDirectives(t *testing.T) {
+	fixture := MergeTestFixture{
+		Input1: `
+			directive @localize(locale: String!) on FIELD
+			type Query {
+				title: String!
+			}
+		`,
+		Input2: `
+			directive @localize(locale: String) on FIELD
+			type Query {
+				description: String!
+			}
+		`,
+		Error: "conflicting directive @localize (definitions must be identical)",
+	}
+	fixture.CheckError(t)
+}
@@ -35,6 +35,27 @@ var (
 		},
 	)
 
+	// promServiceRetryCounter is a counter of retried downstream queries
+	promServiceRetryCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_retry_total",
+			Help: "A counter indicating how many times queries to services have been retried",
+		},
+		[]string{
+			"service",
+		},
+	)
+
+	promServiceDeduplicatedRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_deduplicated_requests_total",
+			Help: "A counter indicating how many requests to services shared an identical in-flight request",
+		},
+		[]string{
+			"service",
+		},
+	)
+
 	promServiceUpdateErrorGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "service_update_error",
@@ -45,6 +66,75 @@ var (
 		},
 	)
 
+	// promServiceCircuitOpenGauge is a gauge of the services whose circuit breaker is open
+	promServiceCircuitOpenGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "service_circuit_open",
+			Help: "A gauge indicating what services have an open or half-open circuit breaker",
+		},
+		[]string{
+			"service",
+		},
+	)
+
+	// promQueryPlanCacheRequests is a counter of query plan cache lookups
+	promQueryPlanCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "query_plan_cache_requests_total",
+			Help: "A counter of query plan cache lookups by result (hit or miss)",
+		},
+		[]string{
+			"result",
+		},
+	)
+
+	// promResponseCacheRequests is a counter of response cache lookups
+	promResponseCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "response_cache_requests_total",
+			Help: "A counter of response cache lookups by result (hit or miss)",
+		},
+		[]string{
+			"result",
+		},
+	)
+
+	// promBoundaryCacheRequests is a counter of boundary cache lookups
+	promBoundaryCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "boundary_cache_requests_total",
+			Help: "A counter of boundary entities looked up in the boundary cache by type and result (hit or miss)",
+		},
+		[]string{
+			"type",
+			"result",
+		},
+	)
+
+	// promShadowRequests is a counter of the queries mirrored to candidate services
+	promShadowRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_requests_total",
+			Help: "A counter of queries mirrored to candidate services by service and result (match, mismatch, error or dropped)",
+		},
+		[]string{
+			"service",
+			"result",
+		},
+	)
+
+	// promShadowMismatches is a counter of the fields differing between services and their candidates
+	promShadowMismatches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_mismatches_total",
+			Help: "A counter of fields differing between the responses of services and their candidates by service and field coordinate",
+		},
+		[]string{
+			"service",
+			"field",
+		},
+	)
+
 	// promHTTPInFlightGauge is a gauge of requests currently being served by the wrapped handler
 	promHTTPInFlightGauge = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "http_in_flight_requests",
@@ -96,7 +186,15 @@ func RegisterMetrics() {
 	prometheus.MustRegister(promInvalidSchema)
 	prometheus.MustRegister(promServiceTimeoutErrorCounter)
 	prometheus.MustRegister(promServiceUpdateErrorCounter)
+	prometheus.MustRegister(promServiceRetryCounter)
+	prometheus.MustRegister(promServiceDeduplicatedRequestCounter)
 	prometheus.MustRegister(promServiceUpdateErrorGauge)
+	prometheus.MustRegister(promServiceCircuitOpenGauge)
+	prometheus.MustRegister(promQueryPlanCacheRequests)
+	prometheus.MustRegister(promResponseCacheRequests)
+	prometheus.MustRegister(promBoundaryCacheRequests)
+	prometheus.MustRegister(promShadowRequests)
+	prometheus.MustRegister(promShadowMismatches)
 	prometheus.MustRegister(promHTTPInFlightGauge)
 	prometheus.MustRegister(promHTTPRequestCounter)
 	prometheus.MustRegister(promHTTPResponseDurations)
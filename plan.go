@@ -28,6 +28,7 @@ type executionStepResult struct {
 	executed  bool
 	error     error
 	timeTaken time.Duration
+	retries   int
 }
 
 func (e *executionStepResult) MarshalJSON() ([]byte, error) {
@@ -35,10 +36,12 @@ func (e *executionStepResult) MarshalJSON() ([]byte, error) {
 		Executed  bool
 		Error     error `json:",omitempty"`
 		TimeTaken string
+		Retries   int `json:",omitempty"`
 	}{
 		Executed:  e.executed,
 		TimeTaken: e.timeTaken.String(),
 		Error:     e.error,
+		Retries:   e.retries,
 	})
 }
 
@@ -67,6 +70,9 @@ func (s *QueryPlanStep) MarshalJSON() ([]byte, error) {
 // QueryPlan is a query execution plan
 type QueryPlan struct {
 	RootSteps []*QueryPlanStep
+	// Routes are the URLs chosen by the traffic splits, keyed by field
+	// coordinate or service URL
+	Routes map[string]string `json:",omitempty"`
 }
 
 // PlanningContext contains the necessary information used to plan a query.
@@ -76,6 +82,34 @@ type PlanningContext struct {
 	Locations  FieldURLMap
 	IsBoundary map[string]bool
 	Services   map[string]*Service
+	// Routes are the URLs chosen by the traffic splits for the request
+	Routes map[string]string
+	// IDFieldName is the id field name of the boundary types without a
+	// declared key, "id" when empty
+	IDFieldName string
+}
+
+// idFieldName returns the id field name of the boundary types without a
+// declared key
+func (ctx *PlanningContext) idFieldName() string {
+	return SchemaOptions{IDFieldName: ctx.IDFieldName}.idFieldName()
+}
+
+// urlFor returns the location of the field. Fields split by a traffic split
+// are routed to the chosen service if it declares the field.
+func (ctx *PlanningContext) urlFor(parent, parentLocation, field string) (string, error) {
+	location, err := ctx.Locations.URLFor(parent, parentLocation, field)
+	if err != nil || len(ctx.Routes) == 0 {
+		return location, err
+	}
+	route, ok := ctx.Routes[ctx.Locations.keyFor(parent, field)]
+	if !ok {
+		return location, nil
+	}
+	if schema := serviceSchema(ctx, route); schema == nil || schema.Types[parent] == nil || schema.Types[parent].Fields.ForName(field) == nil {
+		return location, nil
+	}
+	return route, nil
 }
 
 // Plan returns a query plan from the given planning context
@@ -86,16 +120,28 @@ func Plan(ctx *PlanningContext) (*QueryPlan, error) {
 		parentType = queryObjectName
 	case ast.Mutation:
 		parentType = mutationObjectName
+	case ast.Subscription:
+		parentType = subscriptionObjectName
 	default:
 		return nil, fmt.Errorf("not implemented")
 	}
 
-	steps, err := createSteps(ctx, nil, parentType, "", ctx.Operation.SelectionSet)
+	var steps []*QueryPlanStep
+	var err error
+	if parentType == mutationObjectName {
+		steps, err = createSerialSteps(ctx, parentType, ctx.Operation.SelectionSet)
+	} else {
+		steps, err = createSteps(ctx, nil, parentType, "", ctx.Operation.SelectionSet)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := checkStepsDirectives(ctx, steps); err != nil {
+		return nil, err
+	}
 	return &QueryPlan{
 		RootSteps: steps,
+		Routes:    ctx.Routes,
 	}, nil
 }
 
@@ -139,32 +185,109 @@ func createSteps(ctx *PlanningContext, insertionPoint []string, parentType strin
 	return result, nil
 }
 
-var reservedAliases = map[string]string{
-	"_bramble__typename": "__typename",
-	"_bramble_id":        IdFieldName,
+// createSerialSteps creates the root steps for a mutation. Root mutation
+// fields must be executed serially, so consecutive fields owned by the same
+// service are grouped and the resulting steps follow the document order.
+func createSerialSteps(ctx *PlanningContext, parentType string, selectionSet ast.SelectionSet) ([]*QueryPlanStep, error) {
+	var result []*QueryPlanStep
+	var batch ast.SelectionSet
+	var batchLocation string
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		steps, err := createSteps(ctx, nil, parentType, "", batch)
+		if err != nil {
+			return err
+		}
+		result = append(result, steps...)
+		batch = nil
+		return nil
+	}
+
+	for _, field := range selectionSetToFields(selectionSet) {
+		location, err := ctx.urlFor(parentType, "", field.Name)
+		if err != nil || location == "" {
+			// namespaces and builtin fields are planned on their own
+			location = ""
+		}
+		if location == "" || location != batchLocation {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		batch = append(batch, field)
+		batchLocation = location
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reservedAliases returns the aliases reserved for system use and the fields
+// they must select on the parent type
+func reservedAliases(ctx *PlanningContext, parentType string) map[string]string {
+	idFieldName := ctx.idFieldName()
+	if def := ctx.Schema.Types[parentType]; def != nil && isBoundaryObject(def) {
+		idFieldName = boundaryIDFieldName(def, idFieldName)
+	}
+	return map[string]string{
+		"_bramble__typename": "__typename",
+		"_bramble_id":        idFieldName,
+	}
 }
 
 func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentType string, input ast.SelectionSet, location string) (ast.SelectionSet, []*QueryPlanStep, error) {
 	var selectionSetResult []ast.Selection
 	var childrenStepsResult []*QueryPlanStep
 	var remoteSelections []ast.Selection
-	for _, selection := range input {
+	// selections depending on fields owned by another service, by location
+	// of the required fields
+	var dependentLocations []string
+	dependentSelections := map[string][]ast.Selection{}
+	aliases := reservedAliases(ctx, parentType)
+	for _, selection := range expandShareableInterfaceFragments(ctx, parentType, input, location) {
 		switch selection := selection.(type) {
 		case *ast.Field:
-			for reservedAlias, requiredName := range reservedAliases {
+			for reservedAlias, requiredName := range aliases {
 				if selection.Alias == reservedAlias && selection.Name != requiredName {
 					return nil, nil, gqlerror.Errorf("%s.%s: alias \"%s\" is reserved for system use", strings.Join(insertionPoint, "."), reservedAlias, reservedAlias)
 				}
 			}
-			if parentType != queryObjectName && parentType != mutationObjectName && ctx.IsBoundary[parentType] && selection.Name == IdFieldName {
+			if parentType != queryObjectName && parentType != mutationObjectName && ctx.IsBoundary[parentType] &&
+				selection.Definition != nil && isBoundaryKeyField(ctx.Schema.Types[parentType], selection.Definition, ctx.idFieldName()) {
 				selectionSetResult = append(selectionSetResult, selection)
 				continue
 			}
-			loc, err := ctx.Locations.URLFor(parentType, location, selection.Name)
+			loc, err := ctx.urlFor(parentType, location, selection.Name)
 			// Errors are returned for unmapped namespace/interface locations (needs refactor)
 			if err == nil && loc != location {
 				// field transitions to another service location
-				remoteSelections = append(remoteSelections, selection)
+				required := serviceRequiredFields(ctx, loc, parentType, selection.Name)
+				if len(required) == 0 {
+					remoteSelections = append(remoteSelections, selection)
+					continue
+				}
+
+				// the required fields are fetched first, either with the
+				// current selection set or by the step the field depends on
+				requiredLocation, requiredSelections, err := requiredFieldSelections(ctx, parentType, location, selection.Name, required)
+				if err != nil {
+					return nil, nil, err
+				}
+				if requiredLocation == location {
+					selectionSetResult = appendRequiredSelections(selectionSetResult, requiredSelections)
+					remoteSelections = append(remoteSelections, selection)
+					continue
+				}
+				remoteSelections = appendRequiredSelections(remoteSelections, requiredSelections)
+				if _, ok := dependentSelections[requiredLocation]; !ok {
+					dependentLocations = append(dependentLocations, requiredLocation)
+				}
+				dependentSelections[requiredLocation] = append(dependentSelections[requiredLocation], selection)
 			} else if selection.SelectionSet == nil {
 				// field is a leaf type in the current service
 				selectionSetResult = append(selectionSetResult, selection)
@@ -186,6 +309,13 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 				childrenStepsResult = append(childrenStepsResult, childrenSteps...)
 			}
 		case *ast.InlineFragment:
+			applies, err := fragmentAppliesInService(ctx, parentType, selection.TypeCondition, location)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !applies {
+				continue
+			}
 			selectionSet, childrenSteps, err := extractSelectionSet(
 				ctx,
 				insertionPoint,
@@ -201,6 +331,13 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 			selectionSetResult = append(selectionSetResult, &inlineFragment)
 			childrenStepsResult = append(childrenStepsResult, childrenSteps...)
 		case *ast.FragmentSpread:
+			applies, err := fragmentAppliesInService(ctx, parentType, selection.Definition.TypeCondition, location)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !applies {
+				continue
+			}
 			selectionSet, childrenSteps, err := extractSelectionSet(
 				ctx,
 				insertionPoint,
@@ -213,6 +350,7 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 			}
 			inlineFragment := ast.InlineFragment{
 				TypeCondition: selection.Definition.TypeCondition,
+				Directives:    selection.Directives,
 				SelectionSet:  selectionSet,
 			}
 			selectionSetResult = append(selectionSetResult, &inlineFragment)
@@ -228,6 +366,21 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 		if err != nil {
 			return nil, nil, err
 		}
+
+		// steps depending on required fields are executed after the step
+		// fetching them
+		for _, requiredLocation := range dependentLocations {
+			dependentSteps, err := createSteps(ctx, insertionPoint, parentType, location, dependentSelections[requiredLocation])
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, step := range childrenSteps {
+				if step.ServiceURL == requiredLocation {
+					step.Then = append(step.Then, dependentSteps...)
+					break
+				}
+			}
+		}
 		childrenStepsResult = append(childrenStepsResult, childrenSteps...)
 	}
 
@@ -236,7 +389,7 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 		mergedSteps := []*QueryPlanStep{}
 		mergedStepsMap := map[string]*QueryPlanStep{}
 		for _, step := range childrenStepsResult {
-			key := strings.Join(append([]string{step.ServiceURL}, step.InsertionPoint...), "/")
+			key := strings.Join(append([]string{step.ServiceURL, step.ParentType}, step.InsertionPoint...), "/")
 			if existingStep, ok := mergedStepsMap[key]; ok {
 				existingStep.SelectionSet = append(existingStep.SelectionSet, step.SelectionSet...)
 				existingStep.Then = append(existingStep.Then, step.Then...)
@@ -257,7 +410,7 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 		// implementations. This assures that abstract boundaries always return
 		// with an id, even if they didn't make a selection on the returned type
 		for implementationName, abstractTypes := range ctx.Schema.Implements {
-			if !ctx.IsBoundary[implementationName] {
+			if !ctx.IsBoundary[implementationName] || !possibleTypeInService(ctx, parentType, implementationName, location) {
 				continue
 			}
 			for _, abstractType := range abstractTypes {
@@ -266,10 +419,10 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 				}
 				implementationType := ctx.Schema.Types[implementationName]
 
-				if idDef := implementationType.Fields.ForName(IdFieldName); idDef != nil {
+				if keySelections := boundaryKeySelections(ctx, implementationType); len(keySelections) > 0 {
 					possibleId := &ast.InlineFragment{
 						TypeCondition:    implementationName,
-						SelectionSet:     []ast.Selection{&ast.Field{Alias: "_bramble_id", Name: IdFieldName, Definition: idDef}},
+						SelectionSet:     keySelections,
 						ObjectDefinition: implementationType,
 					}
 					selectionSetResult = append(selectionSetResult, possibleId)
@@ -284,9 +437,9 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 		})
 	} else if parentType != queryObjectName && parentType != mutationObjectName && ctx.IsBoundary[parentType] {
 		// Otherwise, add an id selection to all boundary types
-		if idDef := parentDef.Fields.ForName(IdFieldName); idDef != nil {
+		if keySelections := boundaryKeySelections(ctx, parentDef); len(keySelections) > 0 {
+			selectionSetResult = append(selectionSetResult, keySelections...)
 			selectionSetResult = append(selectionSetResult,
-				&ast.Field{Alias: "_bramble_id", Name: IdFieldName, Definition: idDef},
 				&ast.Field{Alias: "_bramble__typename", Name: "__typename", Definition: &ast.FieldDefinition{Name: "__typename", Type: ast.NamedType("String", nil)}},
 			)
 		}
@@ -294,6 +447,225 @@ func extractSelectionSet(ctx *PlanningContext, insertionPoint []string, parentTy
 	return selectionSetResult, childrenStepsResult, nil
 }
 
+// serviceRequiredFields returns the fields required by the field of the
+// service, declared with the @requires directive
+func serviceRequiredFields(ctx *PlanningContext, location, parentType, fieldName string) []string {
+	schema := serviceSchema(ctx, location)
+	if schema == nil || schema.Types[parentType] == nil {
+		return nil
+	}
+	return requiredFields(schema.Types[parentType].Fields.ForName(fieldName))
+}
+
+// requiredFieldSelections returns the location of the required fields and
+// their selections. The required fields must be owned by a single service.
+func requiredFieldSelections(ctx *PlanningContext, parentType, location, fieldName string, required []string) (string, []ast.Selection, error) {
+	parentDef := ctx.Schema.Types[parentType]
+	if parentDef == nil {
+		return "", nil, fmt.Errorf("definition is nil for parentType %v", parentType)
+	}
+
+	var requiredLocation string
+	var selections []ast.Selection
+	for _, name := range required {
+		def := parentDef.Fields.ForName(name)
+		if def == nil {
+			return "", nil, fmt.Errorf("%s.%s requires unknown field %q", parentType, fieldName, name)
+		}
+		loc := location
+		if !isBoundaryKeyField(parentDef, def, ctx.idFieldName()) {
+			if l, err := ctx.urlFor(parentType, location, name); err == nil {
+				loc = l
+			}
+		}
+		if requiredLocation != "" && loc != requiredLocation {
+			return "", nil, fmt.Errorf("%s.%s: required fields must be owned by a single service", parentType, fieldName)
+		}
+		requiredLocation = loc
+		selections = append(selections, &ast.Field{
+			Alias:            requiresAliasPrefix + name,
+			Name:             name,
+			Definition:       def,
+			ObjectDefinition: parentDef,
+		})
+	}
+	return requiredLocation, selections, nil
+}
+
+// appendRequiredSelections appends the required field selections that are not
+// already selected
+func appendRequiredSelections(selectionSet []ast.Selection, required []ast.Selection) []ast.Selection {
+	for _, r := range required {
+		alias := r.(*ast.Field).Alias
+		found := false
+		for _, s := range selectionSet {
+			if f, ok := s.(*ast.Field); ok && f.Alias == alias {
+				found = true
+				break
+			}
+		}
+		if !found {
+			selectionSet = append(selectionSet, r)
+		}
+	}
+	return selectionSet
+}
+
+// boundaryKeySelections returns the selections of the key of a boundary type,
+// used to look it up in other services. Types without a composite key are
+// looked up by id.
+func boundaryKeySelections(ctx *PlanningContext, t *ast.Definition) []ast.Selection {
+	if !hasCompositeBoundaryKey(t) {
+		idFieldName := boundaryIDFieldName(t, ctx.idFieldName())
+		if idDef := t.Fields.ForName(idFieldName); idDef != nil {
+			return []ast.Selection{&ast.Field{Alias: "_bramble_id", Name: idFieldName, Definition: idDef}}
+		}
+		return nil
+	}
+
+	var result []ast.Selection
+	for _, name := range declaredBoundaryKey(t) {
+		if def := t.Fields.ForName(name); def != nil {
+			result = append(result, &ast.Field{Alias: boundaryKeyAliasPrefix + name, Name: name, Definition: def})
+		}
+	}
+	return result
+}
+
+// expandShareableInterfaceFragments replaces the fragments on shareable
+// interfaces the service doesn't define with fragments on each implementation
+// the service can return. The fields of the implementations are then routed
+// to the services owning them.
+func expandShareableInterfaceFragments(ctx *PlanningContext, parentType string, input ast.SelectionSet, location string) ast.SelectionSet {
+	schema := serviceSchema(ctx, location)
+	if schema == nil {
+		return input
+	}
+
+	var result ast.SelectionSet
+	for _, selection := range input {
+		var typeCondition string
+		var selectionSet ast.SelectionSet
+		var directives ast.DirectiveList
+		switch selection := selection.(type) {
+		case *ast.InlineFragment:
+			typeCondition, selectionSet, directives = selection.TypeCondition, selection.SelectionSet, selection.Directives
+		case *ast.FragmentSpread:
+			typeCondition, selectionSet, directives = selection.Definition.TypeCondition, selection.Definition.SelectionSet, selection.Directives
+		}
+
+		if typeCondition == "" || schema.Types[typeCondition] != nil || !isShareableInterface(ctx.Schema.Types[typeCondition]) {
+			result = append(result, selection)
+			continue
+		}
+
+		for _, possibleType := range ctx.Schema.PossibleTypes[typeCondition] {
+			if !possibleTypeInService(ctx, parentType, possibleType.Name, location) {
+				continue
+			}
+			result = append(result, &ast.InlineFragment{
+				TypeCondition:    possibleType.Name,
+				Directives:       directives,
+				SelectionSet:     selectionSet,
+				ObjectDefinition: possibleType,
+			})
+		}
+	}
+	return result
+}
+
+// fragmentAppliesInService returns whether a fragment on the type condition
+// can be sent to the service. Fragments on types unknown to the service, or on
+// another object type, can never match. An error is returned for fragments on
+// an abstract type unknown to the service that types of the service implement,
+// as their fields can't be fetched from the service.
+func fragmentAppliesInService(ctx *PlanningContext, parentType, typeCondition, location string) (bool, error) {
+	if typeCondition == "" || typeCondition == parentType {
+		return true, nil
+	}
+	parentDef, conditionDef := ctx.Schema.Types[parentType], ctx.Schema.Types[typeCondition]
+	if parentDef != nil && conditionDef != nil && parentDef.Kind == ast.Object && conditionDef.Kind == ast.Object {
+		return false, nil
+	}
+	schema := serviceSchema(ctx, location)
+	if schema == nil || schema.Types[typeCondition] != nil {
+		return true, nil
+	}
+	// the definition of the type condition may be filtered from the schema,
+	// its possible types are kept
+	for _, possibleType := range ctx.Schema.PossibleTypes[typeCondition] {
+		if possibleType.Name != typeCondition && possibleTypeInService(ctx, parentType, possibleType.Name, location) {
+			return false, gqlerror.Errorf("fragment on %q can't be resolved for type %q by service %s, which doesn't define %q: interfaces implemented across services must be declared @shareable", typeCondition, possibleType.Name, ctx.Services[location].Name, typeCondition)
+		}
+	}
+	return false, nil
+}
+
+// possibleTypeInService returns whether the service can return the object
+// type for a selection on the parent type
+func possibleTypeInService(ctx *PlanningContext, parentType, typeName, location string) bool {
+	if parentType == typeName {
+		return true
+	}
+	schema := serviceSchema(ctx, location)
+	if schema == nil {
+		return true
+	}
+	parentDef := schema.Types[parentType]
+	if parentDef == nil || !parentDef.IsAbstractType() {
+		return false
+	}
+	return ast.DefinitionList(schema.PossibleTypes[parentType]).ForName(typeName) != nil
+}
+
+// serviceSchema returns the schema of the service at the location, if known
+func serviceSchema(ctx *PlanningContext, location string) *ast.Schema {
+	service, ok := ctx.Services[location]
+	if !ok || service == nil {
+		return nil
+	}
+	return service.Schema
+}
+
+// checkStepsDirectives returns an error if a directive of the steps is not
+// defined by the service the step is sent to. The directives are forwarded to
+// the services as is.
+func checkStepsDirectives(ctx *PlanningContext, steps []*QueryPlanStep) error {
+	for _, step := range steps {
+		if schema := serviceSchema(ctx, step.ServiceURL); schema != nil {
+			if err := checkSelectionSetDirectives(schema, step.ServiceName, step.SelectionSet); err != nil {
+				return err
+			}
+		}
+		if err := checkStepsDirectives(ctx, step.Then); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSelectionSetDirectives(schema *ast.Schema, serviceName string, selectionSet ast.SelectionSet) error {
+	for _, selection := range selectionSet {
+		var directives ast.DirectiveList
+		var children ast.SelectionSet
+		switch selection := selection.(type) {
+		case *ast.Field:
+			directives, children = selection.Directives, selection.SelectionSet
+		case *ast.InlineFragment:
+			directives, children = selection.Directives, selection.SelectionSet
+		}
+		for _, d := range directives {
+			if schema.Directives[d.Name] == nil {
+				return gqlerror.Errorf("directive @%s is not supported by service %s", d.Name, serviceName)
+			}
+		}
+		if err := checkSelectionSetDirectives(schema, serviceName, children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func routeSelectionSet(ctx *PlanningContext, parentType string, parentLocation string, input ast.SelectionSet) (map[string]ast.SelectionSet, error) {
 	result := map[string]ast.SelectionSet{}
 	if parentLocation == "" {
@@ -322,7 +694,7 @@ func routeSelectionSet(ctx *PlanningContext, parentType string, parentLocation s
 			if isGraphQLBuiltinName(selection.Name) && parentLocation == "" {
 				continue
 			}
-			loc, err := ctx.Locations.URLFor(parentType, parentLocation, selection.Name)
+			loc, err := ctx.urlFor(parentType, parentLocation, selection.Name)
 			if err != nil {
 				return nil, err
 			}
@@ -353,7 +725,7 @@ func routeSelectionSet(ctx *PlanningContext, parentType string, parentLocation s
 func filterSelectionSetByLoc(ctx *PlanningContext, ss ast.SelectionSet, loc, parentType string) ast.SelectionSet {
 	var res ast.SelectionSet
 	for _, selection := range selectionSetToFields(ss) {
-		fieldLocation, err := ctx.Locations.URLFor(parentType, "", selection.Name)
+		fieldLocation, err := ctx.urlFor(parentType, "", selection.Name)
 		if err != nil {
 			// Namespace
 			subSS := filterSelectionSetByLoc(ctx, selection.SelectionSet, loc, selection.Definition.Type.Name())
@@ -407,6 +779,12 @@ type BoundaryField struct {
 	Argument string
 	// Whether the query is in the array format
 	Array bool
+	// Whether the query returns an interface or a union, the selection set is
+	// then wrapped in a fragment on the boundary type
+	Abstract bool
+	// Input object type of the argument for boundary types with a declared
+	// key, empty if the query takes ids
+	KeyType string
 }
 
 // BoundaryFieldsMap is a mapping service -> type -> boundary query
@@ -414,17 +792,33 @@ type BoundaryFieldsMap map[string]map[string]BoundaryField
 
 // RegisterField registers a boundary field
 func (m BoundaryFieldsMap) RegisterField(serviceURL, typeName string, field string, argument string, array bool) {
+	m.register(serviceURL, typeName, BoundaryField{Field: field, Argument: argument, Array: array})
+}
+
+// RegisterAbstractField registers a boundary field returning an interface or a
+// union for one of its member types. Boundary fields returning the type itself
+// take precedence.
+func (m BoundaryFieldsMap) RegisterAbstractField(serviceURL, typeName string, field string, argument string, array bool) {
+	m.register(serviceURL, typeName, BoundaryField{Field: field, Argument: argument, Array: array, Abstract: true})
+}
+
+func (m BoundaryFieldsMap) register(serviceURL, typeName string, field BoundaryField) {
 	if _, ok := m[serviceURL]; !ok {
 		m[serviceURL] = make(map[string]BoundaryField)
 	}
 
-	// We prefer to use the array based boundary lookup
-	_, exists := m[serviceURL][typeName]
-	if exists && !array {
-		return
+	// We prefer boundary fields returning the type itself, then the array
+	// based boundary lookup
+	if existing, exists := m[serviceURL][typeName]; exists {
+		if field.Abstract && !existing.Abstract {
+			return
+		}
+		if field.Abstract == existing.Abstract && !field.Array {
+			return
+		}
 	}
 
-	m[serviceURL][typeName] = BoundaryField{Field: field, Argument: argument, Array: array}
+	m[serviceURL][typeName] = field
 }
 
 // Query returns the boundary field for the given service and type
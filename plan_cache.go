@@ -0,0 +1,170 @@
+package bramble
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// QueryPlanCache is an LRU cache of query plans. Plans are keyed by the
+// normalized operation, the permissions and the schema generation they were
+// planned for.
+//
+// Query plans are mutated during execution, the cache stores and returns
+// copies of the plans.
+type QueryPlanCache struct {
+	cache *lru.Cache[string, *QueryPlan]
+}
+
+// NewQueryPlanCache returns a query plan cache holding up to size plans
+func NewQueryPlanCache(size int) (*QueryPlanCache, error) {
+	cache, err := lru.New[string, *QueryPlan](size)
+	if err != nil {
+		return nil, fmt.Errorf("error creating query plan cache: %w", err)
+	}
+	return &QueryPlanCache{cache: cache}, nil
+}
+
+func (c *QueryPlanCache) get(key string) (*QueryPlan, bool) {
+	plan, ok := c.cache.Get(key)
+	if !ok {
+		promQueryPlanCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	promQueryPlanCacheRequests.WithLabelValues("hit").Inc()
+	return plan.copy(), true
+}
+
+func (c *QueryPlanCache) add(key string, plan *QueryPlan) {
+	c.cache.Add(key, plan.copy())
+}
+
+func (c *QueryPlanCache) purge() {
+	c.cache.Purge()
+}
+
+// plan returns the query plan for the planning context, from the cache if
+// available.
+func (s *ExecutableSchema) plan(key string, ctx *PlanningContext) (*QueryPlan, error) {
+	if s.PlanCache == nil || key == "" {
+		return Plan(ctx)
+	}
+
+	if plan, ok := s.PlanCache.get(key); ok {
+		return plan, nil
+	}
+
+	plan, err := Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.PlanCache.add(key, plan)
+
+	return plan, nil
+}
+
+// queryPlanCacheKey returns the cache key for the operation, the operation
+// must not have been filtered by the permissions yet. An empty key is returned
+// if plans are not cached.
+func (s *ExecutableSchema) queryPlanCacheKey(operation *ast.OperationDefinition, perms OperationPermissions, hasPerms bool, routes map[string]string) string {
+	if s.PlanCache == nil {
+		return ""
+	}
+
+	permsKey := "none"
+	if hasPerms {
+		permsKey = perms.Fingerprint()
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n%s\n", s.schemaGeneration, permsKey, routesKey(routes))
+	writeOperation(hash, operation)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeOperation writes the normalized operation and the fragments it uses
+func writeOperation(w io.Writer, operation *ast.OperationDefinition) {
+	formatter.NewFormatter(w).FormatQueryDocument(&ast.QueryDocument{
+		Operations: ast.OperationList{operation},
+		Fragments:  collectFragmentDefinitions(operation.SelectionSet, nil, map[string]bool{}),
+	})
+}
+
+func collectFragmentDefinitions(selectionSet ast.SelectionSet, fragments ast.FragmentDefinitionList, seen map[string]bool) ast.FragmentDefinitionList {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			fragments = collectFragmentDefinitions(selection.SelectionSet, fragments, seen)
+		case *ast.InlineFragment:
+			fragments = collectFragmentDefinitions(selection.SelectionSet, fragments, seen)
+		case *ast.FragmentSpread:
+			if seen[selection.Name] {
+				continue
+			}
+			seen[selection.Name] = true
+			fragments = append(fragments, selection.Definition)
+			fragments = collectFragmentDefinitions(selection.Definition.SelectionSet, fragments, seen)
+		}
+	}
+	return fragments
+}
+
+// copy returns a deep copy of the plan, without execution results
+func (p *QueryPlan) copy() *QueryPlan {
+	return &QueryPlan{
+		RootSteps: copySteps(p.RootSteps),
+	}
+}
+
+func copySteps(steps []*QueryPlanStep) []*QueryPlanStep {
+	if steps == nil {
+		return nil
+	}
+	result := make([]*QueryPlanStep, len(steps))
+	for i, step := range steps {
+		result[i] = &QueryPlanStep{
+			ServiceURL:     step.ServiceURL,
+			ServiceName:    step.ServiceName,
+			ParentType:     step.ParentType,
+			SelectionSet:   copySelectionSet(step.SelectionSet),
+			InsertionPoint: step.InsertionPoint,
+			Then:           copySteps(step.Then),
+		}
+	}
+	return result
+}
+
+// copySelectionSet returns a deep copy of the selection set, definitions,
+// arguments and directives are shared.
+func copySelectionSet(selectionSet ast.SelectionSet) ast.SelectionSet {
+	if selectionSet == nil {
+		return nil
+	}
+	result := make(ast.SelectionSet, 0, len(selectionSet))
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field := *selection
+			field.SelectionSet = copySelectionSet(selection.SelectionSet)
+			result = append(result, &field)
+		case *ast.InlineFragment:
+			fragment := *selection
+			fragment.SelectionSet = copySelectionSet(selection.SelectionSet)
+			result = append(result, &fragment)
+		case *ast.FragmentSpread:
+			spread := *selection
+			if selection.Definition != nil {
+				definition := *selection.Definition
+				definition.SelectionSet = copySelectionSet(selection.Definition.SelectionSet)
+				spread.Definition = &definition
+			}
+			result = append(result, &spread)
+		}
+	}
+	return result
+}
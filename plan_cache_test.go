@@ -0,0 +1,153 @@
+package bramble
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestQueryPlanCacheReturnsCopies(t *testing.T) {
+	cache, err := NewQueryPlanCache(10)
+	require.NoError(t, err)
+
+	plan, err := PlanTestFixture1.Plan(t, "{ movies { id title compTitles(limit: 42) { id } } }")
+	require.NoError(t, err)
+	cache.add("key", plan)
+
+	cached, ok := cache.get("key")
+	require.True(t, ok)
+	assert.JSONEq(t, jsonMustMarshal(plan), jsonMustMarshal(cached))
+	assert.NotSame(t, plan.RootSteps[0], cached.RootSteps[0])
+
+	// execution results must not leak between copies
+	cached.RootSteps[0].executionResult = &executionStepResult{executed: true}
+	cached.RootSteps[0].Then[0].SelectionSet = nil
+
+	again, ok := cache.get("key")
+	require.True(t, ok)
+	assert.Nil(t, again.RootSteps[0].executionResult)
+	assert.NotNil(t, again.RootSteps[0].Then[0].SelectionSet)
+}
+
+func TestQueryPlanCacheKey(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Movie {
+		id: ID!
+		title: String
+	}
+
+	type Query {
+		movie(id: ID!): Movie!
+	}`})
+	cache, err := NewQueryPlanCache(10)
+	require.NoError(t, err)
+	es := &ExecutableSchema{MergedSchema: schema, PlanCache: cache}
+
+	key := func(query string, vars map[string]interface{}, perms *OperationPermissions) string {
+		op := gqlparser.MustLoadQuery(schema, query).Operations[0]
+		op = es.evaluateSkipAndInclude(vars, op)
+		if perms == nil {
+			return es.queryPlanCacheKey(op, OperationPermissions{}, false, nil)
+		}
+		return es.queryPlanCacheKey(op, *perms, true, nil)
+	}
+
+	base := key(`query q($skip: Boolean!) { movie(id: "1") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": false}, nil)
+
+	t.Run("ignores formatting", func(t *testing.T) {
+		assert.Equal(t, base, key(`query q($skip: Boolean!) {
+			movie(id: "1") {
+				id
+				title @skip(if: $skip)
+			}
+		}`, map[string]interface{}{"skip": false}, nil))
+	})
+
+	t.Run("depends on skip and include", func(t *testing.T) {
+		assert.NotEqual(t, base, key(`query q($skip: Boolean!) { movie(id: "1") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": true}, nil))
+	})
+
+	t.Run("depends on arguments", func(t *testing.T) {
+		assert.NotEqual(t, base, key(`query q($skip: Boolean!) { movie(id: "2") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": false}, nil))
+	})
+
+	t.Run("depends on fragments", func(t *testing.T) {
+		withID := key(`{ movie(id: "1") { ...F } } fragment F on Movie { id }`, nil, nil)
+		withTitle := key(`{ movie(id: "1") { ...F } } fragment F on Movie { title }`, nil, nil)
+		assert.NotEqual(t, withID, withTitle)
+	})
+
+	t.Run("depends on permissions", func(t *testing.T) {
+		perms := OperationPermissions{AllowedRootQueryFields: AllowedFields{AllowAll: true}}
+		withPerms := key(`query q($skip: Boolean!) { movie(id: "1") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": false}, &perms)
+		assert.NotEqual(t, base, withPerms)
+
+		perms = OperationPermissions{AllowedRootQueryFields: AllowedFields{AllowedSubfields: map[string]AllowedFields{"movie": {AllowAll: true}}}}
+		otherPerms := key(`query q($skip: Boolean!) { movie(id: "1") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": false}, &perms)
+		assert.NotEqual(t, withPerms, otherPerms)
+	})
+
+	t.Run("depends on schema generation", func(t *testing.T) {
+		es.schemaGeneration++
+		assert.NotEqual(t, base, key(`query q($skip: Boolean!) { movie(id: "1") { id title @skip(if: $skip) } }`, map[string]interface{}{"skip": false}, nil))
+	})
+}
+
+func TestQueryExecutionWithPlanCache(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movie": {"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Test title"}}}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				type Movie @boundary {
+					id: ID!
+					release: Int
+				}
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"_0": {"_bramble_id": "1", "_bramble__typename": "Movie", "release": 2007}}}`))
+				}),
+			},
+		},
+		query: `{
+			movie(id: "1") {
+				title
+				release
+			}
+		}`,
+		expected: `{
+			"movie": {
+				"title": "Test title",
+				"release": 2007
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	cache, err := NewQueryPlanCache(10)
+	require.NoError(t, err)
+	es.PlanCache = cache
+
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, 1, cache.cache.Len())
+	f.run(t, es, f.checkSuccess())
+	assert.Equal(t, 1, cache.cache.Len())
+}
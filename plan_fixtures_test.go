@@ -305,7 +305,7 @@ func (f *PlanTestFixture) Plan(t *testing.T, query string) (*QueryPlan, error) {
 		"A": {Name: "A", ServiceURL: "A"},
 		"B": {Name: "B", ServiceURL: "B"},
 		"C": {Name: "C", ServiceURL: "C"},
-	}})
+	}, nil, ""})
 	return actual, err
 }
 
@@ -361,7 +361,7 @@ func (f *PlanTestFixture) CheckNilPointer(t *testing.T, query string) {
 		"A": {Name: "A", ServiceURL: "A"},
 		"B": {Name: "B", ServiceURL: "B"},
 		"C": {Name: "C", ServiceURL: "C"},
-	}})
+	}, nil, ""})
 
 	expectedErrorMsg := "definition is nil for parentType Query"
 	require.EqualErrorf(t, err, expectedErrorMsg, "Error should be: %v, got: %v", expectedErrorMsg, err)
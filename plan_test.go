@@ -3,6 +3,7 @@ package bramble
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -681,6 +682,62 @@ func TestQueryPlanSupportsMutations(t *testing.T) {
 	`)
 }
 
+func TestQueryPlanMutationStepsFollowDocumentOrder(t *testing.T) {
+	f := &PlanTestFixture{
+		Schema: `
+		type Query {
+			ping: Boolean!
+		}
+
+		type Mutation {
+			first: Boolean!
+			second: Boolean!
+			third: Boolean!
+			fourth: Boolean!
+		}
+		`,
+		Locations: map[string]string{
+			"Query.ping":      "A",
+			"Mutation.first":  "A",
+			"Mutation.second": "A",
+			"Mutation.third":  "B",
+			"Mutation.fourth": "A",
+		},
+		IsBoundary: map[string]bool{},
+	}
+
+	// steps must not be sorted, the order is significant for mutations
+	plan, err := f.Plan(t, `mutation { first second third fourth }`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `
+	{
+		"RootSteps": [
+		  {
+			"ServiceURL": "A",
+			"ParentType": "Mutation",
+			"SelectionSet": "{ first second }",
+			"InsertionPoint": null,
+			"Then": null
+		  },
+		  {
+			"ServiceURL": "B",
+			"ParentType": "Mutation",
+			"SelectionSet": "{ third }",
+			"InsertionPoint": null,
+			"Then": null
+		  },
+		  {
+			"ServiceURL": "A",
+			"ParentType": "Mutation",
+			"SelectionSet": "{ fourth }",
+			"InsertionPoint": null,
+			"Then": null
+		  }
+		]
+	  }
+	`, jsonMustMarshal(plan))
+}
+
 func TestQueryPlanWithPaginatedBoundaryType(t *testing.T) {
 	PlanTestFixture5.Check(t, "{ foo { foos { cursor page { id name size } } } }", `
     {
@@ -65,6 +65,7 @@ type service struct {
 	ServiceURL string
 	Schema     string
 	Status     string
+	Circuit    string
 }
 
 type templateVariables struct {
@@ -87,12 +88,17 @@ func (p *AdminUIPlugin) handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, s := range p.executableSchema.Services {
+		var circuit string
+		if s.CircuitBreaker != nil {
+			circuit = string(s.CircuitBreaker.State())
+		}
 		vars.Services = append(vars.Services, service{
 			Name:       s.Name,
 			Version:    s.Version,
 			ServiceURL: s.ServiceURL,
 			Schema:     s.SchemaSource,
 			Status:     s.Status,
+			Circuit:    circuit,
 		})
 	}
 
@@ -107,16 +113,26 @@ func (p *AdminUIPlugin) testSchema(schemaStr string) (string, error) {
 		return "", errors.New(gqlErr.Error())
 	}
 
-	if err := bramble.ValidateSchema(schema); err != nil {
+	opts := bramble.SchemaOptions{IDFieldName: p.executableSchema.IDFieldName}
+	if err := bramble.ValidateSchemaWithOptions(schema, opts); err != nil {
 		return "", err
 	}
 
+	// the tested schema is not a known service
 	schemas := []*ast.Schema{schema}
+	opts.ServiceNames = []string{""}
+	opts.RootFieldOwners = map[string]string{}
 	for _, service := range p.executableSchema.Services {
 		schemas = append(schemas, service.Schema)
+		opts.ServiceNames = append(opts.ServiceNames, service.Name)
+		for coordinate, serviceURL := range p.executableSchema.RootFieldOwners {
+			if serviceURL == service.ServiceURL {
+				opts.RootFieldOwners[coordinate] = service.Name
+			}
+		}
 	}
 
-	result, err := bramble.MergeSchemas(schemas...)
+	result, err := bramble.MergeSchemasWithOptions(opts, schemas...)
 	if err != nil {
 		return "", err
 	}
@@ -195,6 +211,15 @@ const htmlTemplate = `
             font-size: 0.9em;
         }
 
+        .header .circuit {
+            font-size: 0.9em;
+        }
+
+        .header .circuit-open,
+        .header .circuit-half-open {
+            color: #bf4e4e;
+        }
+
         .collapsible {
             display: block;
             background: #f5f2f0;
@@ -290,6 +315,7 @@ const htmlTemplate = `
                 <div class="version">{{.Version}}</div>
                 <div class="url">{{.ServiceURL}}</div>
                 <div class="status">{{.Status}}</div>
+                {{if .Circuit}}<div class="circuit circuit-{{.Circuit}}">Circuit {{.Circuit}}</div>{{end}}
             </div>
             <label class="collapsible">
                 <input type="checkbox" />
@@ -1,10 +1,12 @@
 package plugins
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/movio/bramble"
 	"github.com/stretchr/testify/assert"
@@ -56,3 +58,27 @@ func TestAdminUI(t *testing.T) {
 		assert.NotContains(t, rr.Body.String(), "Schema merged successfully")
 	})
 }
+
+func TestAdminUICircuitBreaker(t *testing.T) {
+	plugin := &AdminUIPlugin{}
+	circuitBreaker := bramble.NewCircuitBreaker("svc-a", 1, time.Minute)
+	circuitBreaker.Record(errors.New("connection refused"))
+	es := &bramble.ExecutableSchema{
+		Services: map[string]*bramble.Service{
+			"svc-a": {
+				ServiceURL:     "svc-a",
+				Schema:         gqlparser.MustLoadSchema(&ast.Source{Input: ``}),
+				CircuitBreaker: circuitBreaker,
+			},
+		},
+	}
+	plugin.Init(es)
+	m := http.NewServeMux()
+	plugin.SetupPrivateMux(m)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, req)
+
+	assert.Contains(t, rr.Body.String(), `<div class="circuit circuit-open">Circuit open</div>`)
+}
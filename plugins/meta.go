@@ -124,19 +124,14 @@ type brambleField struct {
 
 type brambleFields []brambleField
 
-func (f brambleFields) Len() int {
-	return len(f)
-}
-
-func (f brambleFields) Less(i, j int) bool {
-	if f[i].Name == bramble.IdFieldName {
-		return true
-	}
-	return f[i].Name < f[j].Name
-}
-
-func (f brambleFields) Swap(i, j int) {
-	f[i], f[j] = f[j], f[i]
+// sort sorts the fields by name, the id field first
+func (f brambleFields) sort(idFieldName string) {
+	sort.Slice(f, func(i, j int) bool {
+		if (f[i].Name == idFieldName) != (f[j].Name == idFieldName) {
+			return f[i].Name == idFieldName
+		}
+		return f[i].Name < f[j].Name
+	})
 }
 
 type brambleEnumValue struct {
@@ -272,7 +267,7 @@ func (r *metaResolver) brambleType(name string, def *ast.Definition) brambleType
 			Arguments:   args,
 		})
 	}
-	sort.Sort(fields)
+	fields.sort(r.executableSchema.IDFieldName)
 	var enum []brambleEnumValue
 	for _, v := range def.EnumValues {
 		enum = append(enum, brambleEnumValue{
@@ -0,0 +1,145 @@
+package bramble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	// RetryOnTimeout retries requests that timed out
+	RetryOnTimeout = "timeout"
+	// RetryOn5xx retries requests that returned a 5xx status code
+	RetryOn5xx = "5xx"
+	// RetryOnConnectionReset retries requests whose connection was reset
+	RetryOnConnectionReset = "connection-reset"
+
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+)
+
+// RetryPolicy configures the retries of failed downstream queries. Mutations
+// are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// request. Requests are not retried when lower than 2.
+	MaxAttempts            int           `json:"max-attempts"`
+	InitialBackoff         string        `json:"initial-backoff"`
+	InitialBackoffDuration time.Duration `json:"-"`
+	MaxBackoff             string        `json:"max-backoff"`
+	MaxBackoffDuration     time.Duration `json:"-"`
+	// RetryOn lists the errors that are retried, any of "timeout", "5xx"
+	// and "connection-reset". All of them are retried when empty.
+	RetryOn []string `json:"retry-on"`
+}
+
+// load parses and validates the policy
+func (p *RetryPolicy) load() error {
+	var err error
+	if p.InitialBackoff != "" {
+		p.InitialBackoffDuration, err = time.ParseDuration(p.InitialBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid initial backoff: %w", err)
+		}
+	}
+	if p.MaxBackoff != "" {
+		p.MaxBackoffDuration, err = time.ParseDuration(p.MaxBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid max backoff: %w", err)
+		}
+	}
+	for _, retryOn := range p.RetryOn {
+		switch retryOn {
+		case RetryOnTimeout, RetryOn5xx, RetryOnConnectionReset:
+		default:
+			return fmt.Errorf("invalid retry-on value %q", retryOn)
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of the policy, policies used by clients must not be
+// modified when the config is reloaded
+func (p *RetryPolicy) clone() *RetryPolicy {
+	policy := *p
+	policy.RetryOn = append([]string(nil), p.RetryOn...)
+	return &policy
+}
+
+func (p *RetryPolicy) enabled() bool {
+	return p != nil && p.MaxAttempts > 1
+}
+
+// shouldRetry returns whether the request should be retried after the given
+// attempt failed with err.
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if !p.enabled() || attempt >= p.MaxAttempts || err == nil {
+		return false
+	}
+
+	retryOn := p.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = []string{RetryOnTimeout, RetryOn5xx, RetryOnConnectionReset}
+	}
+
+	for _, r := range retryOn {
+		switch r {
+		case RetryOnTimeout:
+			var timeoutErr interface{ Timeout() bool }
+			if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+				return true
+			}
+		case RetryOn5xx:
+			var statusErr *ResponseStatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError {
+				return true
+			}
+		case RetryOnConnectionReset:
+			if errors.Is(err, syscall.ECONNRESET) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next attempt, the delay doubles after
+// every attempt and is jittered between half and the full delay.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, max := p.InitialBackoffDuration, p.MaxBackoffDuration
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+
+	delay := max
+	if attempt-1 < 32 {
+		if d := initial << (attempt - 1); d > 0 && d < max {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// wait waits for the backoff of the attempt, it returns false if the context
+// is done before.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
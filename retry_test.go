@@ -0,0 +1,177 @@
+package bramble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	serverErr := &ResponseStatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	clientErr := &ResponseStatusError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}
+	resetErr := fmt.Errorf("error during request: %w", syscall.ECONNRESET)
+
+	policy := &RetryPolicy{MaxAttempts: 3}
+	assert.True(t, policy.shouldRetry(1, timeoutError{}))
+	assert.True(t, policy.shouldRetry(2, serverErr))
+	assert.True(t, policy.shouldRetry(1, resetErr))
+	assert.False(t, policy.shouldRetry(3, serverErr), "max attempts reached")
+	assert.False(t, policy.shouldRetry(1, clientErr))
+	assert.False(t, policy.shouldRetry(1, errors.New("other error")))
+	assert.False(t, policy.shouldRetry(1, nil))
+
+	policy.RetryOn = []string{RetryOn5xx}
+	assert.True(t, policy.shouldRetry(1, serverErr))
+	assert.False(t, policy.shouldRetry(1, timeoutError{}))
+
+	var noPolicy *RetryPolicy
+	assert.False(t, noPolicy.shouldRetry(1, serverErr))
+	assert.False(t, (&RetryPolicy{MaxAttempts: 1}).shouldRetry(1, serverErr))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoffDuration: 100 * time.Millisecond, MaxBackoffDuration: time.Second}
+	for i := 0; i < 20; i++ {
+		backoff := policy.backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 100*time.Millisecond)
+
+		backoff = policy.backoff(3)
+		assert.GreaterOrEqual(t, backoff, 200*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 400*time.Millisecond)
+
+		backoff = policy.backoff(100)
+		assert.GreaterOrEqual(t, backoff, 500*time.Millisecond)
+		assert.LessOrEqual(t, backoff, time.Second)
+	}
+}
+
+func TestRetryPolicyLoad(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: "10ms", MaxBackoff: "1s", RetryOn: []string{RetryOnTimeout}}
+	require.NoError(t, policy.load())
+	assert.Equal(t, 10*time.Millisecond, policy.InitialBackoffDuration)
+	assert.Equal(t, time.Second, policy.MaxBackoffDuration)
+
+	policy.RetryOn = []string{"4xx"}
+	assert.EqualError(t, policy.load(), `invalid retry-on value "4xx"`)
+}
+
+func TestQueryExecutionRetries(t *testing.T) {
+	var movieCalls, releaseCalls int32
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if atomic.AddInt32(&movieCalls, 1) == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					w.Write([]byte(`{"data": {"movie": {"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Test title"}}}`))
+				}),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				type Movie @boundary {
+					id: ID!
+					release: Int
+				}
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if atomic.AddInt32(&releaseCalls, 1) < 3 {
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+					w.Write([]byte(`{"data": {"_0": {"_bramble_id": "1", "_bramble__typename": "Movie", "release": 2007}}}`))
+				}),
+			},
+		},
+		debug: &DebugInfo{Timing: true, Plan: true},
+		query: `{
+			movie(id: "1") {
+				title
+				release
+			}
+		}`,
+		expected: `{
+			"movie": {
+				"title": "Test title",
+				"release": 2007
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	es.GraphqlClient.RetryPolicy = &RetryPolicy{MaxAttempts: 3, InitialBackoffDuration: time.Millisecond}
+
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Empty(t, resp.Errors)
+		jsonEqWithOrder(t, f.expected, string(resp.Data))
+		assert.Equal(t, 3, resp.Extensions["timings"].(map[string]interface{})["retries"])
+
+		plan := resp.Extensions["plan"].(*QueryPlan)
+		assert.Equal(t, 1, plan.RootSteps[0].executionResult.retries)
+		assert.Equal(t, 2, plan.RootSteps[0].Then[0].executionResult.retries)
+	})
+	assert.Equal(t, int32(2), movieCalls)
+	assert.Equal(t, int32(3), releaseCalls)
+}
+
+func TestQueryExecutionDoesNotRetryMutations(t *testing.T) {
+	var calls int32
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movie: String
+				}
+				type Mutation {
+					createMovie: String
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&calls, 1)
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}),
+			},
+		},
+		query: `mutation { createMovie }`,
+	}
+
+	es := f.setup(t)
+	es.GraphqlClient.RetryPolicy = &RetryPolicy{MaxAttempts: 3, InitialBackoffDuration: time.Millisecond}
+
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		require.Len(t, resp.Errors, 1)
+	})
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRetryPolicyWaitIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	policy := &RetryPolicy{InitialBackoffDuration: time.Hour, MaxBackoffDuration: time.Hour}
+	assert.False(t, policy.wait(ctx, 1))
+}
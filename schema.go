@@ -6,7 +6,46 @@ import (
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
-var IdFieldName = "id"
+// defaultIDFieldName is the id field name of boundary types that don't declare
+// their own id field with the key argument of the @boundary directive, unless
+// another name is configured
+const defaultIDFieldName = "id"
+
+// SchemaOptions configures the validation and the merge of the service schemas
+type SchemaOptions struct {
+	// IDFieldName is the id field name of the boundary types without a
+	// declared key, "id" when empty
+	IDFieldName string
+	// ServiceNames are the names of the services of the merged schemas, in
+	// the same order
+	ServiceNames []string
+	// RootFieldOwners are the names of the services owning shared root
+	// fields, keyed by field coordinate, overriding the @owner directive
+	RootFieldOwners map[string]string
+}
+
+func (o SchemaOptions) idFieldName() string {
+	if strings.TrimSpace(o.IDFieldName) == "" {
+		return defaultIDFieldName
+	}
+	return o.IDFieldName
+}
+
+// rootFieldOwnerIndexes returns the index of the schema owning each configured
+// shared root field, keyed by field coordinate. Owners not in the service
+// names are ignored.
+func (o SchemaOptions) rootFieldOwnerIndexes() map[string]int {
+	owners := make(map[string]int, len(o.RootFieldOwners))
+	for coordinate, name := range o.RootFieldOwners {
+		for i, serviceName := range o.ServiceNames {
+			if serviceName == name {
+				owners[coordinate] = i
+				break
+			}
+		}
+	}
+	return owners
+}
 
 const (
 	nodeRootFieldName      = "node"
@@ -15,12 +54,27 @@ const (
 	serviceRootFieldName   = "service"
 	boundaryDirectiveName  = "boundary"
 	namespaceDirectiveName = "namespace"
+	costDirectiveName      = "cost"
+	shareableDirectiveName = "shareable"
+	requiresDirectiveName  = "requires"
+	ownerDirectiveName     = "owner"
+	overrideDirectiveName  = "override"
+
+	cacheControlDirectiveName = "cacheControl"
+	cacheControlScopeName     = "CacheControlScope"
 
 	queryObjectName        = "Query"
 	mutationObjectName     = "Mutation"
 	subscriptionObjectName = "Subscription"
 
 	internalServiceName = "__bramble"
+
+	boundaryKeyArgumentName = "key"
+	boundaryKeyAliasPrefix  = "_bramble_key_"
+	requiresAliasPrefix     = "_bramble_requires_"
+
+	boundaryIDsVariableName = "_bramble_ids"
+	boundaryIDVariableName  = "_bramble_id"
 )
 
 func isGraphQLBuiltinName(s string) bool {
@@ -0,0 +1,285 @@
+package bramble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// maxShadowQueries is the maximum number of mirrored queries in flight, the
+// queries sampled above the limit are not mirrored
+const maxShadowQueries = 64
+
+// ShadowTraffic mirrors a sample of the queries sent to a service to a
+// candidate service. The candidate responses are compared to the service
+// responses and then discarded.
+type ShadowTraffic struct {
+	Service    string  `json:"service"`     // Service is the URL of the mirrored service.
+	Candidate  string  `json:"candidate"`   // Candidate is the URL receiving the mirrored queries.
+	SampleRate float64 `json:"sample-rate"` // SampleRate is the fraction of the queries mirrored, between 0 and 1.
+}
+
+// validate returns an error if the shadow traffic configuration is invalid
+func (s ShadowTraffic) validate() error {
+	if s.Service == "" || s.Candidate == "" {
+		return errors.New("shadow traffic must have a service and a candidate")
+	}
+	if s.SampleRate < 0 || s.SampleRate > 1 {
+		return fmt.Errorf("shadow traffic %s: sample rate must be between 0 and 1", s.Service)
+	}
+	return nil
+}
+
+// sampleShadowTraffic returns the shadow traffic configuration of the service
+// if the query is part of the mirrored sample
+func (q *queryExecution) sampleShadowTraffic(serviceURL string) (ShadowTraffic, bool) {
+	for _, shadow := range q.shadowTraffic {
+		if shadow.Service == serviceURL {
+			return shadow, rand.Float64() < shadow.SampleRate
+		}
+	}
+	return ShadowTraffic{}, false
+}
+
+// recordedData decodes the response data into value and keeps the raw data
+type recordedData struct {
+	value interface{}
+	raw   []byte
+}
+
+func (d *recordedData) UnmarshalJSON(data []byte) error {
+	d.raw = append(d.raw[:0], data...)
+	return json.Unmarshal(data, d.value)
+}
+
+// unmarshalRecordedData decodes the raw data, no data is recorded when the
+// response data is null
+func unmarshalRecordedData(raw []byte, out interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// shadowRootStep mirrors the request of a root step to the candidate service.
+// The candidate is queried in the background, where the raw primary data is
+// decoded again to be compared.
+func (q *queryExecution) shadowRootStep(step *QueryPlanStep, shadow ShadowTraffic, req *Request, raw []byte) {
+	client := q.client(step.ServiceURL)
+	ctx := context.WithoutCancel(q.ctx)
+	q.mirror(shadow, func() {
+		var primary, candidate map[string]interface{}
+		if err := unmarshalRecordedData(raw, &primary); err != nil {
+			recordShadowResult(shadow, err, shadowMismatches{})
+			return
+		}
+		err := client.Request(ctx, shadow.Candidate, req, &candidate)
+		recordShadowResult(shadow, err, diffValues(step.ParentType, step.SelectionSet, primary, candidate))
+	})
+}
+
+// shadowChildStep mirrors the boundary queries of a child step to the
+// candidate service. The primary boundary results are rebuilt in the
+// background from the raw data of each document, and compared by boundary id.
+func (q *queryExecution) shadowChildStep(step *QueryPlanStep, shadow ShadowTraffic, documents []string, variables []map[string]interface{}, boundaryField BoundaryField, raw [][]byte) {
+	client := q.client(step.ServiceURL)
+	ctx := context.WithoutCancel(q.ctx)
+	q.mirror(shadow, func() {
+		primary, _, err := q.sendBoundaryQuery(documents, variables, boundaryField, 1, func(i int, req *Request, out interface{}) (int, error) {
+			return 0, unmarshalRecordedData(raw[i], out)
+		})
+		if err != nil {
+			recordShadowResult(shadow, err, shadowMismatches{})
+			return
+		}
+		candidate, _, err := q.sendBoundaryQuery(documents, variables, boundaryField, 1, func(i int, req *Request, out interface{}) (int, error) {
+			return 0, client.Request(ctx, shadow.Candidate, req, out)
+		})
+		recordShadowResult(shadow, err, diffBoundaryResults(step.ParentType, step.SelectionSet, primary, candidate))
+	})
+}
+
+// mirror runs the mirrored query in the background. The query is dropped when
+// the maximum number of mirrored queries are in flight.
+func (q *queryExecution) mirror(shadow ShadowTraffic, query func()) {
+	select {
+	case q.shadowQueries <- struct{}{}:
+	default:
+		promShadowRequests.WithLabelValues(shadow.Service, "dropped").Inc()
+		return
+	}
+	go func() {
+		defer func() { <-q.shadowQueries }()
+		query()
+	}()
+}
+
+// recordShadowResult logs and counts the mismatches between the service and
+// the candidate. Mismatches are counted by schema coordinate, the response
+// paths contain the client aliases and are only logged.
+func recordShadowResult(shadow ShadowTraffic, err error, mismatches shadowMismatches) {
+	logger := log.WithFields(log.Fields{
+		"service":   shadow.Service,
+		"candidate": shadow.Candidate,
+	})
+	if err != nil {
+		promShadowRequests.WithLabelValues(shadow.Service, "error").Inc()
+		logger.WithError(err).Warn("shadow query failed")
+		return
+	}
+	if len(mismatches.paths) == 0 {
+		promShadowRequests.WithLabelValues(shadow.Service, "match").Inc()
+		return
+	}
+
+	promShadowRequests.WithLabelValues(shadow.Service, "mismatch").Inc()
+	for _, coordinate := range sortedKeys(mismatches.coordinates) {
+		promShadowMismatches.WithLabelValues(shadow.Service, coordinate).Inc()
+	}
+	logger.WithField("paths", sortedKeys(mismatches.paths)).Warn("shadow response mismatch")
+}
+
+// shadowMismatches are the fields differing between the primary and the
+// candidate responses, by response path and by schema coordinate
+type shadowMismatches struct {
+	paths       map[string]struct{}
+	coordinates map[string]struct{}
+}
+
+func newShadowMismatches() shadowMismatches {
+	return shadowMismatches{
+		paths:       map[string]struct{}{},
+		coordinates: map[string]struct{}{},
+	}
+}
+
+func (m shadowMismatches) add(path, coordinate string) {
+	m.paths[path] = struct{}{}
+	m.coordinates[coordinate] = struct{}{}
+}
+
+// diffBoundaryResults returns the fields differing between the primary and
+// the candidate boundary results, compared by boundary id
+func diffBoundaryResults(parentType string, selectionSet ast.SelectionSet, primary, candidate []interface{}) shadowMismatches {
+	primaryByID, candidateByID := boundaryResultsByID(primary), boundaryResultsByID(candidate)
+	mismatches := newShadowMismatches()
+	for id, p := range primaryByID {
+		collectMismatches(mismatches, parentType, parentType, selectionSet, p, candidateByID[id])
+	}
+	for id := range candidateByID {
+		if _, ok := primaryByID[id]; !ok {
+			mismatches.add(parentType, parentType)
+		}
+	}
+	return mismatches
+}
+
+// boundaryResultsByID returns the boundary results keyed by boundary id, the
+// order of the results depends on the boundary query
+func boundaryResultsByID(results []interface{}) map[string]interface{} {
+	byID := make(map[string]interface{}, len(results))
+	for _, result := range results {
+		obj, ok := result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, err := boundaryIDFromMap(obj)
+		if err != nil {
+			continue
+		}
+		byID[id] = obj
+	}
+	return byID
+}
+
+// diffValues returns the fields differing between the primary and the
+// candidate values. List indexes are not part of the paths.
+func diffValues(parentType string, selectionSet ast.SelectionSet, primary, candidate interface{}) shadowMismatches {
+	mismatches := newShadowMismatches()
+	collectMismatches(mismatches, parentType, parentType, selectionSet, primary, candidate)
+	return mismatches
+}
+
+// collectMismatches adds the fields differing between the primary and the
+// candidate values. The coordinates of the fields are found in the selection
+// set, fields that are not selected count as a mismatch of their parent.
+func collectMismatches(mismatches shadowMismatches, path, coordinate string, selectionSet ast.SelectionSet, primary, candidate interface{}) {
+	switch p := primary.(type) {
+	case map[string]interface{}:
+		c, ok := candidate.(map[string]interface{})
+		if !ok {
+			mismatches.add(path, coordinate)
+			return
+		}
+		for k, v := range p {
+			childCoordinate, childSelectionSet := selectedField(selectionSet, k, coordinate)
+			if _, ok := c[k]; !ok {
+				mismatches.add(path+"."+k, childCoordinate)
+				continue
+			}
+			collectMismatches(mismatches, path+"."+k, childCoordinate, childSelectionSet, v, c[k])
+		}
+		for k := range c {
+			if _, ok := p[k]; !ok {
+				childCoordinate, _ := selectedField(selectionSet, k, coordinate)
+				mismatches.add(path+"."+k, childCoordinate)
+			}
+		}
+	case []interface{}:
+		c, ok := candidate.([]interface{})
+		if !ok || len(c) != len(p) {
+			mismatches.add(path, coordinate)
+			return
+		}
+		for i := range p {
+			collectMismatches(mismatches, path, coordinate, selectionSet, p[i], c[i])
+		}
+	default:
+		if !reflect.DeepEqual(primary, candidate) {
+			mismatches.add(path, coordinate)
+		}
+	}
+}
+
+// selectedField returns the schema coordinate and the selection set of the
+// field selected with the response key, or the parent coordinate if the key
+// isn't selected
+func selectedField(selectionSet ast.SelectionSet, key, parentCoordinate string) (string, ast.SelectionSet) {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Alias != key || selection.Definition == nil || selection.ObjectDefinition == nil {
+				continue
+			}
+			return fmt.Sprintf("%s.%s", selection.ObjectDefinition.Name, selection.Definition.Name), selection.SelectionSet
+		case *ast.InlineFragment:
+			if coordinate, selectionSet := selectedField(selection.SelectionSet, key, ""); coordinate != "" {
+				return coordinate, selectionSet
+			}
+		case *ast.FragmentSpread:
+			if selection.Definition == nil {
+				continue
+			}
+			if coordinate, selectionSet := selectedField(selection.Definition.SelectionSet, key, ""); coordinate != "" {
+				return coordinate, selectionSet
+			}
+		}
+	}
+	return parentCoordinate, nil
+}
+
+func sortedKeys(values map[string]struct{}) []string {
+	result := make([]string, 0, len(values))
+	for v := range values {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,163 @@
+package bramble
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestDiffValues(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Movie {
+			id: ID!
+			title: String!
+			year: Int
+			tags: [String!]!
+		}
+
+		type Query {
+			movies: [Movie!]!
+		}
+	`})
+	selectionSet := gqlparser.MustLoadQuery(schema, `{ films: movies { id name: title ... on Movie { tags } } }`).Operations[0].SelectionSet
+	primary := jsonToInterfaceMap(`{
+		"films": [
+			{ "id": "1", "name": "Jaws", "tags": ["shark"] },
+			{ "id": "2", "name": "Alien", "tags": ["space"] }
+		]
+	}`)
+	candidate := jsonToInterfaceMap(`{
+		"films": [
+			{ "id": "1", "name": "Jaws", "tags": ["shark", "sea"] },
+			{ "id": "2", "name": "Aliens", "year": 1979, "tags": ["space"] }
+		]
+	}`)
+
+	assert.Empty(t, diffValues("Query", selectionSet, primary, primary).paths)
+	mismatches := diffValues("Query", selectionSet, primary, candidate)
+	assert.Equal(t, []string{
+		"Query.films.name",
+		"Query.films.tags",
+		"Query.films.year",
+	}, sortedKeys(mismatches.paths))
+	assert.Equal(t, []string{
+		"Movie.tags",
+		"Movie.title",
+		"Query.movies",
+	}, sortedKeys(mismatches.coordinates))
+}
+
+func TestDiffBoundaryResults(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Movie {
+			id: ID!
+			title: String!
+		}
+
+		type Query {
+			movie(id: ID!): Movie
+		}
+	`})
+	selectionSet := gqlparser.MustLoadQuery(schema, `{ movie(id: "1") { name: title } }`).Operations[0].SelectionSet[0].(*ast.Field).SelectionSet
+	primary := jsonToInterfaceSlice(`[
+		{ "_bramble_id": "1", "name": "Jaws" },
+		{ "_bramble_id": "2", "name": "Alien" }
+	]`)
+	candidate := jsonToInterfaceSlice(`[
+		{ "_bramble_id": "2", "name": "Alien" },
+		{ "_bramble_id": "1", "name": "Jaws 2" }
+	]`)
+
+	assert.Empty(t, diffBoundaryResults("Movie", selectionSet, primary, []interface{}{primary[1], primary[0]}).paths)
+	mismatches := diffBoundaryResults("Movie", selectionSet, primary, candidate)
+	assert.Equal(t, []string{"Movie.name"}, sortedKeys(mismatches.paths))
+	assert.Equal(t, []string{"Movie.title"}, sortedKeys(mismatches.coordinates))
+	assert.Equal(t, []string{"Movie"}, sortedKeys(diffBoundaryResults("Movie", selectionSet, primary, candidate[:1]).coordinates))
+}
+
+func TestQueryExecutionWithShadowTraffic(t *testing.T) {
+	mirrored := make(chan string, 1)
+	candidate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"data": {"movies": [{"title": "Aliens"}]}}`))
+		mirrored <- string(b)
+	}))
+	t.Cleanup(candidate.Close)
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Movie {
+					title: String!
+				}
+
+				type Query {
+					movies: [Movie!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movies": [{"title": "Alien"}]}}`))
+				}),
+			},
+		},
+		query: `{
+			movies {
+				title
+			}
+		}`,
+		expected: `{
+			"movies": [{ "title": "Alien" }]
+		}`,
+	}
+
+	es := f.setup(t)
+	var serviceURL string
+	for url := range es.Services {
+		serviceURL = url
+		es.ShadowTraffic = []ShadowTraffic{{Service: url, Candidate: candidate.URL, SampleRate: 1}}
+	}
+	f.run(t, es, f.checkSuccess())
+
+	select {
+	case body := <-mirrored:
+		assert.Contains(t, body, "movies")
+	case <-time.After(time.Second):
+		require.Fail(t, "query was not mirrored to the candidate")
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(promShadowMismatches.WithLabelValues(serviceURL, "Movie.title")) == 1
+	}, time.Second, time.Millisecond, "the mismatch should be counted by field coordinate")
+}
+
+func TestShadowQueriesAreBounded(t *testing.T) {
+	q := &queryExecution{shadowQueries: make(chan struct{}, 1)}
+	shadow := ShadowTraffic{Service: "http://movies/query"}
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	q.mirror(shadow, func() {
+		<-release
+		close(done)
+	})
+	q.mirror(shadow, func() {
+		assert.Fail(t, "the query should be dropped")
+	})
+
+	close(release)
+	<-done
+	require.Eventually(t, func() bool { return len(q.shadowQueries) == 0 }, time.Second, time.Millisecond)
+	ran := make(chan struct{})
+	q.mirror(shadow, func() { close(ran) })
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		require.Fail(t, "the query should be mirrored once a slot is released")
+	}
+}
@@ -0,0 +1,360 @@
+package bramble
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// graphqlWSSubprotocol is the websocket subprotocol used to subscribe to
+// downstream services (subscriptions-transport-ws protocol).
+const graphqlWSSubprotocol = "graphql-ws"
+
+const (
+	gqlConnectionInit      = "connection_init"
+	gqlConnectionAck       = "connection_ack"
+	gqlConnectionError     = "connection_error"
+	gqlConnectionKeepAlive = "ka"
+	gqlConnectionTerminate = "connection_terminate"
+	gqlStart               = "start"
+	gqlStop                = "stop"
+	gqlData                = "data"
+	gqlError               = "error"
+	gqlComplete            = "complete"
+
+	subscriptionID = "1"
+)
+
+type graphqlWSMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// SubscriptionEvent is a single event received from a downstream subscription
+type SubscriptionEvent struct {
+	Data map[string]interface{}
+	Err  error
+}
+
+// Subscribe starts a subscription on the given service using the graphql-ws
+// protocol. Events are sent on the returned channel until the service completes
+// the subscription or the context is cancelled, the channel is then closed.
+func (c *GraphQLClient) Subscribe(ctx context.Context, url string, request *Request) (<-chan SubscriptionEvent, error) {
+	ctx, span := c.tracer.Start(ctx, "GraphQL Subscription",
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			semconv.GraphqlOperationTypeSubscription,
+			semconv.GraphqlOperationName(request.OperationName),
+			semconv.GraphqlDocument(request.Query),
+		),
+	)
+
+	traceErr := func(err error) error {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return err
+	}
+
+	header := http.Header{}
+	if request.Headers != nil {
+		header = request.Headers.Clone()
+	}
+	for name, values := range c.Headers {
+		header[name] = values
+	}
+	if c.UserAgent != "" {
+		header.Set("User-Agent", c.UserAgent)
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: c.HTTPClient.Timeout,
+		Subprotocols:     []string{graphqlWSSubprotocol},
+		TLSClientConfig:  c.tlsConfig,
+	}
+
+	conn, _, err := dialer.DialContext(ctx, websocketURL(url), header)
+	if err != nil {
+		return nil, traceErr(fmt.Errorf("error during websocket handshake: %w", err))
+	}
+
+	if c.MaxResponseSize > 0 {
+		conn.SetReadLimit(c.MaxResponseSize)
+	}
+
+	if err := c.startSubscription(conn, request); err != nil {
+		conn.Close()
+		return nil, traceErr(err)
+	}
+
+	events := make(chan SubscriptionEvent)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.WriteJSON(graphqlWSMessage{ID: subscriptionID, Type: gqlStop})
+			_ = conn.WriteJSON(graphqlWSMessage{Type: gqlConnectionTerminate})
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	go func() {
+		defer span.End()
+		defer close(events)
+		defer close(done)
+
+		send := func(event SubscriptionEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			var msg graphqlWSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				if ctx.Err() == nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					span.RecordError(err)
+					send(SubscriptionEvent{Err: fmt.Errorf("error reading subscription message: %w", err)})
+				}
+				return
+			}
+
+			switch msg.Type {
+			case gqlData:
+				var data map[string]interface{}
+				response := Response{Data: &data}
+				if err := json.Unmarshal(msg.Payload, &response); err != nil {
+					send(SubscriptionEvent{Err: fmt.Errorf("error decoding subscription data: %w", err)})
+					return
+				}
+				event := SubscriptionEvent{Data: data}
+				if len(response.Errors) > 0 {
+					event.Err = response.Errors
+				}
+				if !send(event) {
+					return
+				}
+			case gqlError:
+				var errs GraphqlErrors
+				if err := json.Unmarshal(msg.Payload, &errs); err != nil {
+					// some servers send a single error object
+					var gqlErr GraphqlError
+					_ = json.Unmarshal(msg.Payload, &gqlErr)
+					errs = GraphqlErrors{gqlErr}
+				}
+				send(SubscriptionEvent{Err: errs})
+				return
+			case gqlComplete:
+				return
+			case gqlConnectionKeepAlive:
+			default:
+				log.WithField("type", msg.Type).Debug("ignoring unexpected subscription message")
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// startSubscription initializes the graphql-ws connection and starts the
+// subscription operation.
+func (c *GraphQLClient) startSubscription(conn *websocket.Conn, request *Request) error {
+	if err := conn.WriteJSON(graphqlWSMessage{Type: gqlConnectionInit, Payload: json.RawMessage("{}")}); err != nil {
+		return fmt.Errorf("unable to initialize subscription connection: %w", err)
+	}
+
+	if timeout := c.HTTPClient.Timeout; timeout > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	}
+
+	for {
+		var msg graphqlWSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("error waiting for subscription connection ack: %w", err)
+		}
+		if msg.Type == gqlConnectionAck {
+			break
+		}
+		if msg.Type == gqlConnectionError {
+			return fmt.Errorf("subscription connection error: %s", string(msg.Payload))
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("unable to encode subscription payload: %w", err)
+	}
+
+	if err := conn.WriteJSON(graphqlWSMessage{ID: subscriptionID, Type: gqlStart, Payload: payload}); err != nil {
+		return fmt.Errorf("unable to start subscription: %w", err)
+	}
+
+	return nil
+}
+
+func websocketURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		return "wss://" + strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		return "ws://" + strings.TrimPrefix(url, "http://")
+	default:
+		return url
+	}
+}
+
+// ExecuteSubscription plans the subscription operation, subscribes to the
+// service owning the root field and returns a response handler emitting a
+// response for every event. Boundary fields are resolved for each event.
+func (s *ExecutableSchema) ExecuteSubscription(ctx context.Context) graphql.ResponseHandler {
+	operationCtx := graphql.GetOperationContext(ctx)
+	operation := operationCtx.Operation
+	variables := operationCtx.Variables
+
+	for _, plugin := range s.plugins {
+		plugin.InterceptRequest(ctx, operation.Name, operationCtx.RawQuery, variables)
+	}
+
+	AddField(ctx, "operation.name", operation.Name)
+	AddField(ctx, "operation.type", operation.Operation)
+
+	if err := s.checkTrustedDocument(ctx, operationCtx.RawQuery); err != nil {
+		return graphql.OneShot(s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Errors: gqlerror.List{err},
+		}))
+	}
+
+	s.mutex.RLock()
+	// The op passed in is a cached value
+	// so it must be copied before modification
+	operation = s.evaluateSkipAndInclude(variables, operation)
+	filteredSchema := s.MergedSchema
+	boundaryQueries := s.BoundaryQueries
+	serviceClients := s.ServiceClients
+	services := s.Services
+	boundaryCache := s.BoundaryCache
+	boundaryBatching := s.BoundaryBatching
+
+	var permsErrs gqlerror.List
+	perms, hasPerms := GetPermissionsFromContext(ctx)
+	if hasPerms {
+		filteredSchema = s.filteredSchema(perms)
+		permsErrs = perms.FilterAuthorizedFields(operation)
+	}
+
+	limitsErr := s.QueryLimits.Check(operation, variables)
+
+	plan, err := Plan(&PlanningContext{
+		Operation:   operation,
+		Schema:      filteredSchema,
+		Locations:   s.Locations,
+		IsBoundary:  s.IsBoundary,
+		Services:    s.Services,
+		IDFieldName: s.IDFieldName,
+	})
+	s.mutex.RUnlock()
+
+	errorResponse := func(errs gqlerror.List) graphql.ResponseHandler {
+		return graphql.OneShot(s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Errors: errs,
+		}))
+	}
+
+	if limitsErr != nil {
+		return errorResponse(append(permsErrs, limitsErr))
+	}
+
+	if err != nil {
+		return errorResponse(gqlerror.List{gqlerror.Errorf("%s", err)})
+	}
+
+	if len(plan.RootSteps) == 0 {
+		return errorResponse(permsErrs)
+	}
+
+	if len(plan.RootSteps) != 1 || plan.RootSteps[0].ServiceURL == internalServiceName {
+		return errorResponse(gqlerror.List{gqlerror.Errorf("subscriptions must select a single field from a federated service")})
+	}
+
+	step := plan.RootSteps[0]
+	document, stepVariables := formatDocument(ctx, filteredSchema, step.ParentType, step.SelectionSet)
+	req := NewRequest(document).
+		WithVariables(stepVariables).
+		WithHeaders(GetOutgoingRequestHeadersFromContext(ctx)).
+		WithOperationName(operationCtx.OperationName).
+		WithOperationType(step.ParentType)
+
+	client := s.GraphqlClient
+	if serviceClient, ok := serviceClients[step.ServiceURL]; ok {
+		client = serviceClient
+	}
+
+	events, err := client.Subscribe(ctx, step.ServiceURL, req)
+	if err != nil {
+		qe := newQueryExecution(ctx, operationCtx.OperationName, s.GraphqlClient, filteredSchema, boundaryQueries, int32(s.MaxRequestsPerQuery))
+		qe.serviceClients = serviceClients
+		qe.services = services
+		qe.boundaryCache = boundaryCache
+		qe.boundaryBatching = boundaryBatching
+		return errorResponse(qe.createGQLErrors(step, err))
+	}
+
+	return func(ctx context.Context) *graphql.Response {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+
+		timings := make(map[string]interface{})
+		qe := newQueryExecution(ctx, operationCtx.OperationName, s.GraphqlClient, filteredSchema, boundaryQueries, int32(s.MaxRequestsPerQuery))
+		qe.serviceClients = serviceClients
+		qe.services = services
+		qe.boundaryCache = boundaryCache
+		qe.boundaryBatching = boundaryBatching
+		results, executeErrs := qe.ExecuteSubscriptionEvent(step, event.Data, event.Err)
+		if len(executeErrs) > 0 {
+			return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+				Errors: executeErrs,
+			})
+		}
+
+		errs := append(gqlerror.List{}, permsErrs...)
+		for _, result := range results {
+			errs = append(errs, result.Errors...)
+		}
+
+		data, bubbleErrs, err := formatExecutionResults(filteredSchema, operation.SelectionSet, results, timings)
+		if err != nil {
+			errs = append(errs, &gqlerror.Error{Message: err.Error()})
+			return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+				Errors: errs,
+			})
+		}
+		errs = append(errs, bubbleErrs...)
+
+		return s.interceptResponse(ctx, operation.Name, operationCtx.RawQuery, variables, &graphql.Response{
+			Data:   data,
+			Errors: errs,
+		})
+	}
+}
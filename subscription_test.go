@@ -0,0 +1,202 @@
+package bramble
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func subscriptionHandler(t *testing.T, events ...string) http.Handler {
+	upgrader := websocket.Upgrader{Subprotocols: []string{graphqlWSSubprotocol}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var msg graphqlWSMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, gqlConnectionInit, msg.Type)
+		require.NoError(t, conn.WriteJSON(graphqlWSMessage{Type: gqlConnectionAck}))
+
+		require.NoError(t, conn.ReadJSON(&msg))
+		require.Equal(t, gqlStart, msg.Type)
+		var req Request
+		require.NoError(t, json.Unmarshal(msg.Payload, &req))
+		assert.Equal(t, "subscription", req.OperationType)
+
+		for _, event := range events {
+			require.NoError(t, conn.WriteJSON(graphqlWSMessage{ID: msg.ID, Type: gqlData, Payload: json.RawMessage(event)}))
+		}
+		require.NoError(t, conn.WriteJSON(graphqlWSMessage{ID: msg.ID, Type: gqlComplete}))
+
+		// wait for the client to close the connection
+		for {
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func TestSubscriptionWithBoundaryFields(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `directive @boundary on OBJECT
+				type Movie @boundary {
+					id: ID!
+					title: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}
+				type Subscription {
+					movieAdded: Movie!
+				}`,
+				handler: subscriptionHandler(t,
+					`{"data": {"movieAdded": {"_bramble_id": "1", "_bramble__typename": "Movie", "title": "Test title 1"}}}`,
+					`{"data": {"movieAdded": {"_bramble_id": "2", "_bramble__typename": "Movie", "title": "Test title 2"}}}`,
+				),
+			},
+			{
+				schema: `directive @boundary on OBJECT | FIELD_DEFINITION
+				type Movie @boundary {
+					id: ID!
+					release: Int
+				}
+				type Query {
+					movie(id: ID!): Movie @boundary
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var req Request
+					json.NewDecoder(r.Body).Decode(&req)
+					if req.Variables["_bramble_id_0"] == "1" {
+						w.Write([]byte(`{"data": {"_0": {"_bramble_id": "1", "_bramble__typename": "Movie", "release": 2007}}}`))
+					} else {
+						w.Write([]byte(`{"data": {"_0": {"_bramble_id": "2", "_bramble__typename": "Movie", "release": 2008}}}`))
+					}
+				}),
+			},
+		},
+		query: `subscription {
+			movieAdded {
+				title
+				release
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	query := gqlparser.MustLoadQuery(f.mergedSchema, f.query)
+	ctx, cancel := context.WithCancel(testContextWithVariables(map[string]interface{}{}, query.Operations[0]))
+	defer cancel()
+
+	responses := es.Exec(ctx)
+
+	resp := responses(ctx)
+	require.NotNil(t, resp)
+	require.Empty(t, resp.Errors)
+	jsonEqWithOrder(t, `{"movieAdded": {"title": "Test title 1", "release": 2007}}`, string(resp.Data))
+
+	resp = responses(ctx)
+	require.NotNil(t, resp)
+	require.Empty(t, resp.Errors)
+	jsonEqWithOrder(t, `{"movieAdded": {"title": "Test title 2", "release": 2008}}`, string(resp.Data))
+
+	assert.Nil(t, responses(ctx))
+}
+
+func TestSubscriptionHonorsPermissions(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Movie {
+					id: ID!
+					title: String
+					secret: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}
+				type Subscription {
+					movieAdded: Movie!
+				}`,
+				handler: subscriptionHandler(t,
+					`{"data": {"movieAdded": {"title": "Test title 1"}}}`,
+					`{"data": {"movieAdded": {"title": "Test title 2"}}}`,
+				),
+			},
+		},
+		query: `subscription {
+			movieAdded {
+				title
+				secret
+			}
+		}`,
+	}
+
+	es := f.setup(t)
+	query := gqlparser.MustLoadQuery(f.mergedSchema, f.query)
+	ctx := graphql.WithResponseContext(graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Variables: map[string]interface{}{},
+		Operation: query.Operations[0],
+	}), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+	ctx = AddPermissionsToContext(ctx, OperationPermissions{
+		AllowedRootSubscriptionFields: AllowedFields{
+			AllowedSubfields: map[string]AllowedFields{
+				"movieAdded": {AllowedSubfields: map[string]AllowedFields{"title": {AllowAll: true}}},
+			},
+		},
+	})
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := es.Exec(ctx)
+
+	for _, title := range []string{"Test title 1", "Test title 2"} {
+		resp := responses(ctx)
+		require.NotNil(t, resp)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "subscription.movieAdded.secret access disallowed", resp.Errors[0].Message)
+		jsonEqWithOrder(t, `{"movieAdded": {"title": "`+title+`"}}`, string(resp.Data))
+	}
+
+	assert.Nil(t, responses(ctx))
+}
+
+func TestSubscriptionPlan(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Movie {
+		id: ID!
+		title: String
+	}
+	type Query {
+		movie(id: ID!): Movie!
+	}
+	type Subscription {
+		movieAdded: Movie!
+	}`})
+	locations := FieldURLMap{}
+	locations.RegisterURL("Subscription", "movieAdded", "A")
+	locations.RegisterURL("Movie", "title", "A")
+
+	query := gqlparser.MustLoadQuery(schema, `subscription { movieAdded { title } }`)
+	plan, err := Plan(&PlanningContext{
+		Operation: query.Operations[0],
+		Schema:    schema,
+		Locations: locations,
+		Services:  map[string]*Service{"A": {ServiceURL: "A", Name: "A"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, plan.RootSteps, 1)
+	assert.Equal(t, "Subscription", plan.RootSteps[0].ParentType)
+	assert.Equal(t, "A", plan.RootSteps[0].ServiceURL)
+}
@@ -0,0 +1,123 @@
+package bramble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// TrafficSplit sends a share of the requests for a field or for a whole
+// service to other URLs
+type TrafficSplit struct {
+	Field   string               `json:"field"`   // Field is the coordinate of the split field (e.g. "Query.movies"), its targets are services declaring the field.
+	Service string               `json:"service"` // Service is the URL of the split service, its targets are other deployments of the service.
+	Targets []TrafficSplitTarget `json:"targets"`
+}
+
+// TrafficSplitTarget is a URL receiving a share of the requests of a traffic
+// split
+type TrafficSplitTarget struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"` // Weight is the percentage of requests sent to the target.
+	Header string `json:"header"` // Header sends the requests with the header to the target, regardless of the weight.
+	Value  string `json:"value"`  // Value restricts the header routing to a header value.
+}
+
+// validate returns an error if the traffic split is invalid
+func (s TrafficSplit) validate() error {
+	if (s.Field == "") == (s.Service == "") {
+		return errors.New("traffic split must have either a field or a service")
+	}
+	if s.Field != "" && !isFieldCoordinate(s.Field) {
+		return fmt.Errorf("traffic split: invalid field %q, expected Type.field", s.Field)
+	}
+	total := 0
+	for _, target := range s.Targets {
+		if target.URL == "" {
+			return fmt.Errorf("traffic split %s: target url is required", s.key())
+		}
+		if target.Weight < 0 {
+			return fmt.Errorf("traffic split %s: weight must be positive", s.key())
+		}
+		total += target.Weight
+	}
+	if total > 100 {
+		return fmt.Errorf("traffic split %s: total weight must be at most 100", s.key())
+	}
+	return nil
+}
+
+// key returns the field coordinate or the service URL of the split
+func (s TrafficSplit) key() string {
+	if s.Field != "" {
+		return s.Field
+	}
+	return s.Service
+}
+
+// route returns the target URL chosen for a request with the headers. The
+// requests not sent to a target keep their original URL.
+func (s TrafficSplit) route(headers http.Header) (string, bool) {
+	for _, target := range s.Targets {
+		if target.Header == "" {
+			continue
+		}
+		if value := headers.Get(target.Header); value != "" && (target.Value == "" || value == target.Value) {
+			return target.URL, true
+		}
+	}
+
+	n := rand.Intn(100)
+	for _, target := range s.Targets {
+		if n < target.Weight {
+			return target.URL, true
+		}
+		n -= target.Weight
+	}
+	return "", false
+}
+
+// routeTraffic returns the URLs chosen for the request, keyed by field
+// coordinate or service URL
+func routeTraffic(ctx context.Context, splits []TrafficSplit) map[string]string {
+	if len(splits) == 0 {
+		return nil
+	}
+	headers := GetIncomingRequestHeadersFromContext(ctx)
+	routes := make(map[string]string)
+	for _, split := range splits {
+		if url, ok := split.route(headers); ok {
+			routes[split.key()] = url
+		}
+	}
+	return routes
+}
+
+// routesKey returns a string representation of the routes, used to cache the
+// plans of every route
+func routesKey(routes map[string]string) string {
+	keys := make([]string, 0, len(routes))
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "%s=%s;", key, routes[key])
+	}
+	return sb.String()
+}
+
+// trafficSplitMiddleware adds the request headers to the context, they are
+// used to route the requests of the traffic splits
+func trafficSplitMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := AddIncomingRequestHeadersToContext(r.Context(), r.Header)
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
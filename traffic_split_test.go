@@ -0,0 +1,180 @@
+package bramble
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficSplitRoute(t *testing.T) {
+	split := TrafficSplit{
+		Service: "http://movies/query",
+		Targets: []TrafficSplitTarget{
+			{URL: "http://movies-canary/query", Header: "X-Canary", Value: "true"},
+			{URL: "http://movies-v2/query", Weight: 100},
+		},
+	}
+
+	url, ok := split.route(http.Header{"X-Canary": []string{"true"}})
+	assert.True(t, ok)
+	assert.Equal(t, "http://movies-canary/query", url)
+
+	url, ok = split.route(http.Header{"X-Canary": []string{"false"}})
+	assert.True(t, ok)
+	assert.Equal(t, "http://movies-v2/query", url)
+
+	split.Targets[1].Weight = 0
+	_, ok = split.route(http.Header{})
+	assert.False(t, ok)
+}
+
+func TestTrafficSplitValidate(t *testing.T) {
+	assert.NoError(t, TrafficSplit{Field: "Query.movies", Targets: []TrafficSplitTarget{{URL: "http://movies-v2/query", Weight: 5}}}.validate())
+	assert.EqualError(t, TrafficSplit{Targets: []TrafficSplitTarget{{URL: "http://movies-v2/query"}}}.validate(), "traffic split must have either a field or a service")
+	assert.EqualError(t, TrafficSplit{Field: "movies"}.validate(), `traffic split: invalid field "movies", expected Type.field`)
+	assert.EqualError(t, TrafficSplit{Service: "http://movies/query", Targets: []TrafficSplitTarget{{Weight: 5}}}.validate(), "traffic split http://movies/query: target url is required")
+	assert.EqualError(t, TrafficSplit{
+		Service: "http://movies/query",
+		Targets: []TrafficSplitTarget{
+			{URL: "http://movies-v2/query", Weight: 60},
+			{URL: "http://movies-v3/query", Weight: 60},
+		},
+	}.validate(), "traffic split http://movies/query: total weight must be at most 100")
+}
+
+func TestQueryExecutionWithServiceTrafficSplit(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"movies": ["new"]}}`))
+	}))
+	t.Cleanup(target.Close)
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movies: [String!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					t.Error("query sent to the split service")
+				}),
+			},
+		},
+		debug: &DebugInfo{Plan: true},
+		query: `{
+			movies
+		}`,
+		expected: `{
+			"movies": ["new"]
+		}`,
+	}
+
+	es := f.setup(t)
+	var serviceURL string
+	for url := range es.Services {
+		serviceURL = url
+	}
+	es.TrafficSplits = []TrafficSplit{{
+		Service: serviceURL,
+		Targets: []TrafficSplitTarget{{URL: target.URL, Weight: 100}},
+	}}
+	f.run(t, es, func(t *testing.T, resp *graphql.Response) {
+		f.checkSuccess()(t, resp)
+		plan, ok := resp.Extensions["plan"].(*QueryPlan)
+		require.True(t, ok)
+		assert.Equal(t, map[string]string{serviceURL: target.URL}, plan.Routes)
+	})
+}
+
+func TestQueryExecutionWithFieldTrafficSplit(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Query {
+					movies: [String!]!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movies": ["old"]}}`))
+				}),
+			},
+			{
+				schema: `directive @owner on FIELD_DEFINITION
+
+				type Query {
+					movies: [String!]! @owner
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					t.Error("query sent to the owner")
+				}),
+			},
+		},
+		query: `{
+			movies
+		}`,
+		expected: `{
+			"movies": ["old"]
+		}`,
+	}
+
+	es := f.setup(t)
+	for url, service := range es.Services {
+		if service.Schema.Query.Fields.ForName("movies").Directives.ForName("owner") == nil {
+			es.TrafficSplits = []TrafficSplit{{
+				Field:   "Query.movies",
+				Targets: []TrafficSplitTarget{{URL: url, Weight: 100}},
+			}}
+		}
+	}
+	f.run(t, es, f.checkSuccess())
+}
+
+func TestQueryExecutionWithTrafficSplitAndResponseCache(t *testing.T) {
+	schema := `directive @cacheControl(maxAge: Int) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+
+	type Query {
+		movies: [String!]! @cacheControl(maxAge: 60)
+	}`
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"movies": ["new"]}}`))
+	}))
+	t.Cleanup(target.Close)
+
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: schema,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movies": ["old"]}}`))
+				}),
+			},
+		},
+		query: `{
+			movies
+		}`,
+		expected: `{
+			"movies": ["old"]
+		}`,
+	}
+
+	es := f.setup(t)
+	cache, err := NewInMemoryResponseCache(10)
+	require.NoError(t, err)
+	es.ResponseCache = cache
+	f.run(t, es, f.checkSuccess())
+
+	var serviceURL string
+	for url := range es.Services {
+		serviceURL = url
+	}
+	es.TrafficSplits = []TrafficSplit{{
+		Service: serviceURL,
+		Targets: []TrafficSplitTarget{{URL: target.URL, Weight: 100}},
+	}}
+	f.expected = `{
+		"movies": ["new"]
+	}`
+	f.run(t, es, f.checkSuccess())
+}
@@ -0,0 +1,107 @@
+package bramble
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errUntrustedDocumentCode = "UNTRUSTED_DOCUMENT"
+
+// TrustedDocuments is a safelist of operations indexed by the SHA-256 hash of
+// their document. When configured on the executable schema, only operations in
+// the safelist can be executed.
+//
+// TrustedDocuments implements graphql.Cache so that clients can send the hash
+// of a trusted document in the persisted query extension instead of the full
+// document.
+type TrustedDocuments struct {
+	mutex     sync.RWMutex
+	documents map[string]string
+}
+
+// NewTrustedDocuments returns a safelist containing the given documents, indexed
+// by their hash.
+func NewTrustedDocuments(documents map[string]string) *TrustedDocuments {
+	return &TrustedDocuments{documents: documents}
+}
+
+// LoadTrustedDocumentsManifest reads a JSON manifest mapping SHA-256 hashes to
+// documents.
+func LoadTrustedDocumentsManifest(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var documents map[string]string
+	if err := json.NewDecoder(f).Decode(&documents); err != nil {
+		return nil, fmt.Errorf("error decoding trusted documents manifest %q: %w", path, err)
+	}
+
+	for hash, document := range documents {
+		if documentHash(document) != hash {
+			return nil, fmt.Errorf("invalid trusted documents manifest %q: hash %q does not match document", path, hash)
+		}
+	}
+
+	return documents, nil
+}
+
+// Update replaces the documents in the safelist
+func (t *TrustedDocuments) Update(documents map[string]string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.documents = documents
+}
+
+// IsTrusted returns whether the document is in the safelist
+func (t *TrustedDocuments) IsTrusted(document string) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	_, ok := t.documents[documentHash(document)]
+	return ok
+}
+
+// Get returns the document for the given hash
+func (t *TrustedDocuments) Get(_ context.Context, hash string) (interface{}, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	document, ok := t.documents[hash]
+	return document, ok
+}
+
+// Add is a no-op, documents can only be added through the manifest
+func (t *TrustedDocuments) Add(_ context.Context, _ string, _ interface{}) {}
+
+// checkTrustedDocument returns an error if the schema only accepts trusted
+// documents, the document is not trusted and the permissions do not allow
+// untrusted documents.
+func (s *ExecutableSchema) checkTrustedDocument(ctx context.Context, document string) *gqlerror.Error {
+	if s.TrustedDocuments == nil {
+		return nil
+	}
+	if perms, ok := GetPermissionsFromContext(ctx); ok && perms.AllowUntrustedDocuments {
+		return nil
+	}
+	if s.TrustedDocuments.IsTrusted(document) {
+		return nil
+	}
+
+	return &gqlerror.Error{
+		Message:    "operation is not a trusted document",
+		Extensions: map[string]interface{}{"code": errUntrustedDocumentCode},
+	}
+}
+
+func documentHash(document string) string {
+	hash := sha256.Sum256([]byte(document))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,139 @@
+package bramble
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+)
+
+func writeTrustedDocumentsManifest(t *testing.T, path string, documents ...string) {
+	t.Helper()
+	manifest := make(map[string]string)
+	for _, document := range documents {
+		manifest[documentHash(document)] = document
+	}
+	b, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+}
+
+func TestLoadTrustedDocumentsManifest(t *testing.T) {
+	t.Run("valid manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		writeTrustedDocumentsManifest(t, path, "{ movies { title } }")
+
+		documents, err := LoadTrustedDocumentsManifest(path)
+		require.NoError(t, err)
+		trusted := NewTrustedDocuments(documents)
+		assert.True(t, trusted.IsTrusted("{ movies { title } }"))
+		assert.False(t, trusted.IsTrusted("{ movies { id } }"))
+
+		document, ok := trusted.Get(context.Background(), documentHash("{ movies { title } }"))
+		assert.True(t, ok)
+		assert.Equal(t, "{ movies { title } }", document)
+	})
+
+	t.Run("hash mismatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"abc": "{ movies { title } }"}`), 0o644))
+
+		_, err := LoadTrustedDocumentsManifest(path)
+		assert.ErrorContains(t, err, "does not match document")
+	})
+}
+
+func TestTrustedDocumentsExecution(t *testing.T) {
+	f := &queryExecutionFixture{
+		services: []testService{
+			{
+				schema: `type Movie {
+					id: ID!
+					title: String
+				}
+				type Query {
+					movie(id: ID!): Movie!
+				}`,
+				handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"data": {"movie": {"title": "Test title"}}}`))
+				}),
+			},
+		},
+	}
+
+	trustedQuery := `{ movie(id: "1") { title } }`
+	es := f.setup(t)
+	es.TrustedDocuments = NewTrustedDocuments(map[string]string{documentHash(trustedQuery): trustedQuery})
+
+	execute := func(query string, perms OperationPermissions) *graphql.Response {
+		op := gqlparser.MustLoadQuery(f.mergedSchema, query).Operations[0]
+		ctx := graphql.WithResponseContext(graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+			RawQuery:  query,
+			Variables: map[string]interface{}{},
+			Operation: op,
+		}), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+		return es.ExecuteQuery(AddPermissionsToContext(ctx, perms))
+	}
+
+	perms := OperationPermissions{
+		AllowedRootQueryFields: AllowedFields{AllowAll: true},
+	}
+
+	t.Run("trusted document", func(t *testing.T) {
+		resp := execute(trustedQuery, perms)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"movie": {"title": "Test title"}}`, string(resp.Data))
+	})
+
+	t.Run("untrusted document", func(t *testing.T) {
+		resp := execute(`{ movie(id: "1") { id title } }`, perms)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "operation is not a trusted document", resp.Errors[0].Message)
+		assert.Equal(t, errUntrustedDocumentCode, resp.Errors[0].Extensions["code"])
+		assert.Nil(t, resp.Data)
+	})
+
+	t.Run("untrusted document allowed by permissions", func(t *testing.T) {
+		perms := perms
+		perms.AllowUntrustedDocuments = true
+		resp := execute(`{ movie(id: "1") { title } }`, perms)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"movie": {"title": "Test title"}}`, string(resp.Data))
+	})
+}
+
+func TestTrustedDocumentsConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.json")
+	configPath := filepath.Join(dir, "config.json")
+	writeTrustedDocumentsManifest(t, manifestPath, "{ a }")
+	config := `{"services": ["http://localhost:8080/query"], "poll-interval": "5s", "trusted-documents": "` + manifestPath + `"}`
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0o644))
+
+	cfg, err := GetConfig([]string{configPath})
+	require.NoError(t, err)
+	require.NotNil(t, cfg.trustedDocuments)
+	assert.True(t, cfg.trustedDocuments.IsTrusted("{ a }"))
+	assert.False(t, cfg.trustedDocuments.IsTrusted("{ b }"))
+
+	writeTrustedDocumentsManifest(t, manifestPath, "{ a }", "{ b }")
+	require.NoError(t, cfg.Load())
+	assert.True(t, cfg.trustedDocuments.IsTrusted("{ b }"))
+}
+
+func TestOperationPermissionsUntrustedDocumentsJSON(t *testing.T) {
+	var perms OperationPermissions
+	require.NoError(t, json.Unmarshal([]byte(`{"query": "*", "allow-untrusted-documents": true}`), &perms))
+	assert.True(t, perms.AllowUntrustedDocuments)
+
+	b, err := json.Marshal(perms)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"query": "*", "allow-untrusted-documents": true}`, string(b))
+}
@@ -9,28 +9,49 @@ import (
 
 // ValidateSchema validates that the schema respects the Bramble specs
 func ValidateSchema(schema *ast.Schema) error {
+	return ValidateSchemaWithOptions(schema, SchemaOptions{})
+}
+
+// ValidateSchemaWithOptions validates that the schema respects the Bramble
+// specs, using the id field name of the options for boundary types. The
+// service name, if any, is the single name of the options.
+func ValidateSchemaWithOptions(schema *ast.Schema, opts SchemaOptions) error {
+	idFieldName := opts.idFieldName()
+	var serviceName string
+	if len(opts.ServiceNames) == 1 {
+		serviceName = opts.ServiceNames[0]
+	}
 	if err := validateRootObjectNames(schema); err != nil {
 		return err
 	}
-	if err := validateBoundaryObjects(schema); err != nil {
+	if err := validateBoundaryObjects(schema, idFieldName); err != nil {
 		return err
 	}
 	if err := validateNamespaceObjects(schema); err != nil {
 		return err
 	}
+	if err := validateRequiresDirective(schema); err != nil {
+		return err
+	}
+	if err := validateOwnerDirective(schema); err != nil {
+		return err
+	}
+	if err := validateOverrideDirective(schema, idFieldName, serviceName); err != nil {
+		return err
+	}
 	if err := validateServiceQuery(schema); err != nil {
 		return err
 	}
 	if err := validateServiceObject(schema); err != nil {
 		return err
 	}
-	if err := validateSchemaValidAfterMerge(schema); err != nil {
+	if err := validateSchemaValidAfterMerge(schema, opts); err != nil {
 		return err
 	}
 	return nil
 }
 
-func validateBoundaryObjects(schema *ast.Schema) error {
+func validateBoundaryObjects(schema *ast.Schema, idFieldName string) error {
 	if !usesBoundaryDirective(schema) {
 		return nil
 	}
@@ -39,7 +60,7 @@ func validateBoundaryObjects(schema *ast.Schema) error {
 		return err
 	}
 
-	if err := validateBoundaryObjectsFormat(schema); err != nil {
+	if err := validateBoundaryObjectsFormat(schema, idFieldName); err != nil {
 		return err
 	}
 
@@ -55,7 +76,7 @@ func validateBoundaryObjects(schema *ast.Schema) error {
 			}
 		}
 	} else {
-		if err := validateNodeInterface(schema); err != nil {
+		if err := validateNodeInterface(schema, idFieldName); err != nil {
 			return err
 		}
 		if err := validateImplementsNode(schema); err != nil {
@@ -64,7 +85,7 @@ func validateBoundaryObjects(schema *ast.Schema) error {
 	}
 
 	if hasNodeQuery(schema) {
-		if err := validateNodeQuery(schema); err != nil {
+		if err := validateNodeQuery(schema, idFieldName); err != nil {
 			return err
 		}
 	}
@@ -138,7 +159,7 @@ func validateServiceQuery(schema *ast.Schema) error {
 	return fmt.Errorf("the Query type is missing the 'service' field")
 }
 
-func validateNodeQuery(schema *ast.Schema) error {
+func validateNodeQuery(schema *ast.Schema, idFieldName string) error {
 	if schema.Query == nil {
 		return fmt.Errorf("the schema is missing a Query type")
 	}
@@ -150,8 +171,8 @@ func validateNodeQuery(schema *ast.Schema) error {
 			return fmt.Errorf("the 'node' field of Query must take a single argument")
 		}
 		arg := f.Arguments[0]
-		if arg.Name != IdFieldName {
-			return fmt.Errorf("the 'node' field of Query must take a single argument called 'id'")
+		if arg.Name != idFieldName {
+			return fmt.Errorf("the 'node' field of Query must take a single argument called '%s'", idFieldName)
 		}
 		if !isIDType(arg.Type) {
 			return fmt.Errorf("the 'node' field of Query must take a single argument of type 'ID!'")
@@ -164,7 +185,7 @@ func validateNodeQuery(schema *ast.Schema) error {
 	return fmt.Errorf("the Query type is missing the 'node' field")
 }
 
-func validateNodeInterface(schema *ast.Schema) error {
+func validateNodeInterface(schema *ast.Schema, idFieldName string) error {
 	for _, t := range schema.Types {
 		if t.Name != nodeInterfaceName {
 			continue
@@ -176,11 +197,11 @@ func validateNodeInterface(schema *ast.Schema) error {
 			return fmt.Errorf("the Node interface should have exactly one field")
 		}
 		field := t.Fields[0]
-		if field.Name != IdFieldName {
-			return fmt.Errorf("the Node interface should have a field called 'id'")
+		if field.Name != idFieldName {
+			return fmt.Errorf("the Node interface should have a field called '%s'", idFieldName)
 		}
 		if !isIDType(field.Type) {
-			return fmt.Errorf("the Node interface should have a field called 'id' of type 'ID!'")
+			return fmt.Errorf("the Node interface should have a field called '%s' of type 'ID!'", idFieldName)
 		}
 		return nil
 	}
@@ -304,8 +325,14 @@ func validateBoundaryDirective(schema *ast.Schema) error {
 		if d.Name != boundaryDirectiveName {
 			continue
 		}
-		if len(d.Arguments) != 0 {
-			return fmt.Errorf("@boundary directive may not take arguments")
+		for _, arg := range d.Arguments {
+			// the key argument declares the key fields of boundary types
+			if arg.Name != boundaryKeyArgumentName {
+				return fmt.Errorf("@boundary directive may not take arguments")
+			}
+			if arg.Type.Name() != "String" || arg.Type.Elem != nil {
+				return fmt.Errorf("@boundary directive key argument should have type String")
+			}
 		}
 		if len(d.Locations) == 1 {
 			// compatibility with existing @boundary directives
@@ -343,8 +370,24 @@ func validateBoundaryFields(schema *ast.Schema) error {
 		}
 	}
 
+	// boundary types that are members of an interface or a union returned by
+	// a boundary query
+	abstractBoundaryTypes := make(map[string]bool)
 	for _, f := range schema.Query.Fields {
 		if hasBoundaryDirective(f) {
+			if t := schema.Types[f.Type.Name()]; t != nil && t.IsAbstractType() {
+				if len(f.Arguments) != 1 {
+					return fmt.Errorf("boundary field %q expects exactly one argument", f.Name)
+				}
+				for _, pt := range schema.PossibleTypes[t.Name] {
+					if _, ok := boundaryTypes[pt.Name]; !ok {
+						return fmt.Errorf("declared boundary query for %q with non-boundary type %q", t.Name, pt.Name)
+					}
+					abstractBoundaryTypes[pt.Name] = true
+				}
+				continue
+			}
+
 			hasBoundaryType, ok := boundaryTypes[f.Type.Name()]
 			if !ok {
 				return fmt.Errorf("declared boundary query for non-boundary type %q", f.Type.Name())
@@ -364,7 +407,7 @@ func validateBoundaryFields(schema *ast.Schema) error {
 
 	var missingBoundaryQueries []string
 	for k, hasBoundaryType := range boundaryTypes {
-		if !hasBoundaryType {
+		if !hasBoundaryType && !abstractBoundaryTypes[k] {
 			missingBoundaryQueries = append(missingBoundaryQueries, k)
 		}
 	}
@@ -376,29 +419,53 @@ func validateBoundaryFields(schema *ast.Schema) error {
 	return nil
 }
 
-func validateBoundaryObjectsFormat(schema *ast.Schema) error {
+func validateBoundaryObjectsFormat(schema *ast.Schema, idFieldName string) error {
 	for _, t := range schema.Types {
 		if t.Directives.ForName(boundaryDirectiveName) == nil {
 			continue
 		}
 
-		idField := t.Fields.ForName(IdFieldName)
+		if hasBoundaryKey(t) {
+			if err := validateBoundaryKey(schema, t); err != nil {
+				return err
+			}
+			continue
+		}
+
+		idField := t.Fields.ForName(idFieldName)
 		if idField == nil {
-			return fmt.Errorf(`missing "%s: ID!" field in boundary type %q`, IdFieldName, t.Name)
+			return fmt.Errorf(`missing "%s: ID!" field in boundary type %q`, idFieldName, t.Name)
 		}
 
 		if idField.Type.String() != "ID!" {
-			return fmt.Errorf(`%q field should have type "ID!" in boundary type %q`, IdFieldName, t.Name)
+			return fmt.Errorf(`%q field should have type "ID!" in boundary type %q`, idFieldName, t.Name)
 		}
 	}
 
 	return nil
 }
 
+// validateBoundaryKey checks that the key fields of the boundary type are
+// non-null scalar fields
+func validateBoundaryKey(schema *ast.Schema, t *ast.Definition) error {
+	for _, name := range declaredBoundaryKey(t) {
+		f := t.Fields.ForName(name)
+		if f == nil {
+			return fmt.Errorf("missing key field %q in boundary type %q", name, t.Name)
+		}
+		fieldType := schema.Types[f.Type.Name()]
+		if len(f.Arguments) != 0 || !f.Type.NonNull || f.Type.Elem != nil || fieldType == nil ||
+			(fieldType.Kind != ast.Scalar && fieldType.Kind != ast.Enum) {
+			return fmt.Errorf("key field %q of boundary type %q should be a non-null scalar without arguments", name, t.Name)
+		}
+	}
+	return nil
+}
+
 func validateBoundaryQueries(schema *ast.Schema) error {
 	for _, f := range schema.Query.Fields {
 		if hasBoundaryDirective(f) {
-			if err := validateBoundaryQuery(f); err != nil {
+			if err := validateBoundaryQuery(schema, f); err != nil {
 				return fmt.Errorf("invalid boundary query %q: %w", f.Name, err)
 			}
 		}
@@ -407,11 +474,15 @@ func validateBoundaryQueries(schema *ast.Schema) error {
 	return nil
 }
 
-func validateBoundaryQuery(f *ast.FieldDefinition) error {
+func validateBoundaryQuery(schema *ast.Schema, f *ast.FieldDefinition) error {
 	if len(f.Arguments) != 1 {
 		return fmt.Errorf(`boundary query must have exactly one argument`)
 	}
 
+	if t := schema.Types[f.Type.Name()]; t != nil && hasCompositeBoundaryKey(t) {
+		return validateKeyedBoundaryQuery(schema, f, t)
+	}
+
 	if f.Arguments[0].Type.Elem != nil {
 		// array type check
 		if f.Arguments[0].Type.String() != "[ID!]!" {
@@ -437,6 +508,140 @@ func validateBoundaryQuery(f *ast.FieldDefinition) error {
 	return nil
 }
 
+// validateKeyedBoundaryQuery checks that the boundary query of a type with a
+// declared key takes an input object with the key fields
+func validateKeyedBoundaryQuery(schema *ast.Schema, f *ast.FieldDefinition, t *ast.Definition) error {
+	argType := f.Arguments[0].Type
+	if f.Type.Elem != nil {
+		if !argType.NonNull || argType.Elem == nil || !argType.Elem.NonNull {
+			return fmt.Errorf("boundary list query must accept a non-null list of non-null keys")
+		}
+		if !f.Type.NonNull {
+			return fmt.Errorf("return type should be a non-null array of nullable elements")
+		}
+	} else {
+		if !argType.NonNull || argType.Elem != nil {
+			return fmt.Errorf("boundary query must accept a non-null key")
+		}
+		if f.Type.NonNull {
+			return fmt.Errorf("return type of boundary query should be nullable")
+		}
+	}
+
+	input := schema.Types[argType.Name()]
+	if input == nil || input.Kind != ast.InputObject {
+		return fmt.Errorf("boundary query for %q must accept an input object with the key fields", t.Name)
+	}
+	key := declaredBoundaryKey(t)
+	if len(input.Fields) != len(key) {
+		return fmt.Errorf("input object %q should have exactly the key fields of %q", input.Name, t.Name)
+	}
+	for _, name := range key {
+		field, keyField := input.Fields.ForName(name), t.Fields.ForName(name)
+		if field == nil || keyField == nil || field.Type.String() != keyField.Type.String() {
+			return fmt.Errorf("input object %q should have exactly the key fields of %q", input.Name, t.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateRequiresDirective checks that fields with the @requires directive
+// are fields of boundary objects, taking the required fields as arguments
+func validateRequiresDirective(schema *ast.Schema) error {
+	for _, t := range schema.Types {
+		for _, f := range t.Fields {
+			if f.Directives.ForName(requiresDirectiveName) == nil {
+				continue
+			}
+			required := requiredFields(f)
+			if d := schema.Directives[requiresDirectiveName]; d == nil || d.Arguments.ForName("fields") == nil {
+				return fmt.Errorf("@requires directive should be defined as @requires(fields: String!) on FIELD_DEFINITION")
+			}
+			if t.Kind != ast.Object || !isBoundaryObject(t) {
+				return fmt.Errorf("@requires directive on %s.%s: only fields of boundary objects may require fields", t.Name, f.Name)
+			}
+			if len(required) == 0 {
+				return fmt.Errorf("@requires directive on %s.%s: no required fields", t.Name, f.Name)
+			}
+			for _, name := range required {
+				if f.Arguments.ForName(name) == nil {
+					return fmt.Errorf("@requires directive on %s.%s: missing argument for required field %q", t.Name, f.Name, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateOwnerDirective checks that only Query and Mutation fields are
+// declared as owned with the @owner directive
+func validateOwnerDirective(schema *ast.Schema) error {
+	for _, t := range schema.Types {
+		for _, f := range t.Fields {
+			if f.Directives.ForName(ownerDirectiveName) == nil {
+				continue
+			}
+			if schema.Directives[ownerDirectiveName] == nil {
+				return fmt.Errorf("@owner directive should be defined as @owner on FIELD_DEFINITION")
+			}
+			if t.Name != queryObjectName && t.Name != mutationObjectName {
+				return fmt.Errorf("@owner directive on %s.%s: only Query and Mutation fields can be owned", t.Name, f.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOverrideDirective checks that fields with the @override directive
+// are fields of boundary objects, naming the service they are overriding. A
+// service can't override itself, it is not checked if its name is empty.
+func validateOverrideDirective(schema *ast.Schema, idFieldName, serviceName string) error {
+	for _, t := range schema.Types {
+		for _, f := range t.Fields {
+			if f.Directives.ForName(overrideDirectiveName) == nil {
+				continue
+			}
+			if def := schema.Directives[overrideDirectiveName]; def == nil || def.Arguments.ForName("from") == nil {
+				return fmt.Errorf("@override directive should be defined as @override(from: String!) on FIELD_DEFINITION")
+			}
+			if t.Kind != ast.Object || !isBoundaryObject(t) || isBoundaryKeyField(t, f, idFieldName) {
+				return fmt.Errorf("@override directive on %s.%s: only non key fields of boundary objects can be overridden", t.Name, f.Name)
+			}
+			from := overriddenService(f)
+			if from == "" {
+				return fmt.Errorf("@override directive on %s.%s: missing service name", t.Name, f.Name)
+			}
+			if serviceName != "" && from == serviceName {
+				return fmt.Errorf("@override directive on %s.%s: service %q can't override itself", t.Name, f.Name, from)
+			}
+		}
+	}
+	return nil
+}
+
+// validateOverrideSources checks that the services named by the @override
+// directives exist. The names are those of the services of the schemas.
+func validateOverrideSources(schemas []*ast.Schema, serviceNames []string) error {
+	for i, schema := range schemas {
+		for _, t := range schema.Types {
+			for _, f := range t.Fields {
+				from := overriddenService(f)
+				if from == "" {
+					continue
+				}
+				if from == serviceNames[i] {
+					return fmt.Errorf("@override directive on %s.%s: service %q can't override itself", t.Name, f.Name, from)
+				}
+				if !containsString(serviceNames, from) {
+					return fmt.Errorf("@override directive on %s.%s: unknown service %q", t.Name, f.Name, from)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func validateRootObjectNames(schema *ast.Schema) error {
 	if q := schema.Query; q != nil && q.Name != queryObjectName {
 		return fmt.Errorf("the schema Query type can not be renamed to %s", q.Name)
@@ -453,8 +658,11 @@ func validateRootObjectNames(schema *ast.Schema) error {
 // validateSchemaValidAfterMerge validates that the schema is still going to be
 // valid once it gets merged with another schema and special types are removed.
 // For example the Service type should not be used outside of the Query type.
-func validateSchemaValidAfterMerge(schema *ast.Schema) error {
-	mergedSchema, err := MergeSchemas(schema)
+func validateSchemaValidAfterMerge(schema *ast.Schema, opts SchemaOptions) error {
+	// the services named by the @override directives are checked when the
+	// schema is merged with the other services
+	opts.ServiceNames = nil
+	mergedSchema, err := MergeSchemasWithOptions(opts, schema)
 	if err != nil {
 		return fmt.Errorf("merge schema error: %w", err)
 	}
@@ -35,6 +35,18 @@ func (t *testSchema) assertInvalid(err string, f func(*ast.Schema) error) {
 	}
 }
 
+func withIDFieldName(idFieldName string, f func(*ast.Schema, string) error) func(*ast.Schema) error {
+	return func(schema *ast.Schema) error {
+		return f(schema, idFieldName)
+	}
+}
+
+func withOptions(opts SchemaOptions, f func(*ast.Schema, SchemaOptions) error) func(*ast.Schema) error {
+	return func(schema *ast.Schema) error {
+		return f(schema, opts)
+	}
+}
+
 func TestSchemaIsValid(t *testing.T) {
 	withSchema(t, `
 	directive @boundary on OBJECT
@@ -53,6 +65,21 @@ func TestSchemaIsValid(t *testing.T) {
 	`).assertValid(ValidateSchema)
 }
 
+func TestSchemaWithUnforwardableDirectivesIsValid(t *testing.T) {
+	withSchema(t, `
+	directive @trace on QUERY
+	directive @translate on FRAGMENT_SPREAD
+	type Service {
+		name: String!
+		version: String!
+		schema: String!
+	}
+	type Query {
+		service: Service!
+	}
+	`).assertValid(ValidateSchema)
+}
+
 func TestBoundaryDirectiveRequirements(t *testing.T) {
 	// check @boundary directive matches requirements
 	t.Run("@boundary missing", func(t *testing.T) {
@@ -77,6 +104,14 @@ func TestBoundaryDirectiveRequirements(t *testing.T) {
 		directive @boundary on FIELD | OBJECT
 		`).assertInvalid("@boundary directive should have locations OBJECT | FIELD_DEFINITION", validateBoundaryDirective)
 	})
+	t.Run("@boundary key argument", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+		`).assertValid(validateBoundaryDirective)
+		withSchema(t, `
+		directive @boundary(key: [String!]) on OBJECT | FIELD_DEFINITION
+		`).assertInvalid("@boundary directive key argument should have type String", validateBoundaryDirective)
+	})
 	t.Run("@boundary has no arguments", func(t *testing.T) {
 		withSchema(t, `
 		directive @boundary(id: String) on OBJECT
@@ -88,7 +123,7 @@ func TestBoundaryDirectiveRequirements(t *testing.T) {
 		type Filler {
 			other: String
 		}
-		`).assertValid(validateBoundaryObjects)
+		`).assertValid(withIDFieldName("id", validateBoundaryObjects))
 	})
 	t.Run("@boundary is checked if it is used", func(t *testing.T) {
 		withSchema(t, `
@@ -96,7 +131,7 @@ func TestBoundaryDirectiveRequirements(t *testing.T) {
 		type Filler @boundary {
 			id: ID!
 		}
-		`).assertInvalid("@boundary directive may not take arguments", validateBoundaryObjects)
+		`).assertInvalid("@boundary directive may not take arguments", withIDFieldName("id", validateBoundaryObjects))
 	})
 	t.Run("@boundary is checked if it is used", func(t *testing.T) {
 		withSchema(t, `
@@ -170,86 +205,96 @@ func TestNamespaceDirectiveRequirements(t *testing.T) {
 
 func TestNodeInterface(t *testing.T) {
 	t.Run("Node interface missing", func(t *testing.T) {
-		withSchema(t, "").assertInvalid("the Node interface was not found", validateNodeInterface)
+		withSchema(t, "").assertInvalid("the Node interface was not found", withIDFieldName("id", validateNodeInterface))
 	})
 	t.Run("Node is not interface", func(t *testing.T) {
 		withSchema(t, `
 		type Node {
 			id: ID!
-		}`).assertInvalid("the Node type must be an interface", validateNodeInterface)
+		}`).assertInvalid("the Node type must be an interface", withIDFieldName("id", validateNodeInterface))
 	})
 	t.Run("Node interface has extra fields", func(t *testing.T) {
 		withSchema(t, `
 		interface Node {
 			id: ID!
 			extra: String
-		}`).assertInvalid("the Node interface should have exactly one field", validateNodeInterface)
+		}`).assertInvalid("the Node interface should have exactly one field", withIDFieldName("id", validateNodeInterface))
 	})
 	t.Run("Node interface has incorrect field", func(t *testing.T) {
 		withSchema(t, `
 		interface Node {
 			incorrect: String
-		}`).assertInvalid("the Node interface should have a field called 'id'", validateNodeInterface)
+		}`).assertInvalid("the Node interface should have a field called 'id'", withIDFieldName("id", validateNodeInterface))
 	})
 	t.Run("Node interface has incorrect type", func(t *testing.T) {
 		withSchema(t, `
 		interface Node {
 			id: String
-		}`).assertInvalid("the Node interface should have a field called 'id' of type 'ID!'", validateNodeInterface)
+		}`).assertInvalid("the Node interface should have a field called 'id' of type 'ID!'", withIDFieldName("id", validateNodeInterface))
 	})
 	t.Run("Node interface is correct", func(t *testing.T) {
 		withSchema(t, `
 		interface Node {
 			id: ID!
-		}`).assertValid(validateNodeInterface)
+		}`).assertValid(withIDFieldName("id", validateNodeInterface))
+	})
+	t.Run("Node interface with alternate id field name", func(t *testing.T) {
+		withSchema(t, `
+		interface Node {
+			gid: ID!
+		}`).assertValid(withIDFieldName("gid", validateNodeInterface))
+		withSchema(t, `
+		interface Node {
+			id: ID!
+		}`).assertInvalid("the Node interface should have a field called 'gid'", withIDFieldName("gid", validateNodeInterface))
 	})
 }
 
 func TestNodeQuery(t *testing.T) {
 	t.Run("query type missing", func(t *testing.T) {
-		withSchema(t, "").assertInvalid("the schema is missing a Query type", validateNodeQuery)
+		withSchema(t, "").assertInvalid("the schema is missing a Query type", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("node query missing", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			other: String
 		}
-		`).assertInvalid("the Query type is missing the 'node' field", validateNodeQuery)
+		`).assertInvalid("the Query type is missing the 'node' field", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query with no arguments", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			node: ID!
 		}
-		`).assertInvalid("the 'node' field of Query must take a single argument", validateNodeQuery)
+		`).assertInvalid("the 'node' field of Query must take a single argument", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query with wrong argument name", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			node(incorrect: ID!): ID!
 		}
-		`).assertInvalid("the 'node' field of Query must take a single argument called 'id'", validateNodeQuery)
+		`).assertInvalid("the 'node' field of Query must take a single argument called 'id'", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query with extra argument", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			node(id: ID!, incorrect: String): ID!
 		}
-		`).assertInvalid("the 'node' field of Query must take a single argument", validateNodeQuery)
+		`).assertInvalid("the 'node' field of Query must take a single argument", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query with wrong argument type", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			node(id: String): ID!
 		}
-		`).assertInvalid("the 'node' field of Query must take a single argument of type 'ID!'", validateNodeQuery)
+		`).assertInvalid("the 'node' field of Query must take a single argument of type 'ID!'", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query with wrong type", func(t *testing.T) {
 		withSchema(t, `
 		type Query {
 			node(id: ID!): ID!
 		}
-		`).assertInvalid("the 'node' field of Query must be of type 'Node'", validateNodeQuery)
+		`).assertInvalid("the 'node' field of Query must be of type 'Node'", withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("query is correct", func(t *testing.T) {
 		withSchema(t, `
@@ -259,7 +304,7 @@ func TestNodeQuery(t *testing.T) {
 		type Query {
 			node(id: ID!): Node
 		}
-		`).assertValid(validateNodeQuery)
+		`).assertValid(withIDFieldName("id", validateNodeQuery))
 	})
 	t.Run("Query is checked if @boundary is used", func(t *testing.T) {
 		withSchema(t, `
@@ -272,7 +317,7 @@ func TestNodeQuery(t *testing.T) {
 		}
 		type Gizmo implements Node @boundary {
 			id: ID!
-		}`).assertInvalid("the 'node' field of Query must be of type 'Node'", validateBoundaryObjects)
+		}`).assertInvalid("the 'node' field of Query must be of type 'Node'", withIDFieldName("id", validateBoundaryObjects))
 	})
 }
 
@@ -514,7 +559,7 @@ func TestSchemaValidAfterMerge(t *testing.T) {
 
 		type Mutation {
 			service: Service!
-		}`).assertInvalid("schema will become invalid after merge operation: merged schema:2: Undefined type Service.", validateSchemaValidAfterMerge)
+		}`).assertInvalid("schema will become invalid after merge operation: merged schema:2: Undefined type Service.", withOptions(SchemaOptions{}, validateSchemaValidAfterMerge))
 	})
 
 	t.Run("valid schema with empty Query type", func(t *testing.T) {
@@ -531,7 +576,7 @@ func TestSchemaValidAfterMerge(t *testing.T) {
 
 		type Mutation {
 			a: String!
-		}`).assertValid(validateSchemaValidAfterMerge)
+		}`).assertValid(withOptions(SchemaOptions{}, validateSchemaValidAfterMerge))
 	})
 }
 
@@ -698,6 +743,47 @@ func TestSchemaValidateBoundaryFields(t *testing.T) {
 		}
 		`).assertInvalid(`boundary field "foo" expects exactly one argument`, validateBoundaryFields)
 	})
+
+	t.Run("valid union boundary field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		type Foo @boundary {
+			id: ID!
+		}
+
+		type Bar @boundary {
+			id: ID!
+		}
+
+		union Entity = Foo | Bar
+
+		type Query {
+			foo(id: ID!): Foo @boundary
+			entities(ids: [ID!]!): [Entity]! @boundary
+		}
+		`).assertValid(validateBoundaryFields)
+	})
+
+	t.Run("union boundary field with non-boundary member", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		type Foo @boundary {
+			id: ID!
+		}
+
+		type Bar {
+			id: ID!
+		}
+
+		union Entity = Foo | Bar
+
+		type Query {
+			entity(id: ID!): Entity @boundary
+		}
+		`).assertInvalid(`declared boundary query for "Entity" with non-boundary type "Bar"`, validateBoundaryFields)
+	})
 }
 
 func TestSchemaValidateBoundaryObjectsFormat(t *testing.T) {
@@ -712,7 +798,7 @@ func TestSchemaValidateBoundaryObjectsFormat(t *testing.T) {
 		type Bar @boundary {
 			id: ID!
 		}
-		`).assertValid(validateBoundaryObjectsFormat)
+		`).assertValid(withIDFieldName("id", validateBoundaryObjectsFormat))
 	})
 
 	t.Run("missing id field", func(t *testing.T) {
@@ -722,6 +808,322 @@ func TestSchemaValidateBoundaryObjectsFormat(t *testing.T) {
 		type Foo @boundary {
 			foo: String
 		}
-		`).assertInvalid(`missing "id: ID!" field in boundary type "Foo"`, validateBoundaryObjectsFormat)
+		`).assertInvalid(`missing "id: ID!" field in boundary type "Foo"`, withIDFieldName("id", validateBoundaryObjectsFormat))
+	})
+
+	t.Run("alternate id field name", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		type Foo @boundary {
+			gid: ID!
+		}
+		`).assertValid(withIDFieldName("gid", validateBoundaryObjectsFormat))
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+
+		type Foo @boundary {
+			id: ID!
+		}
+		`).assertInvalid(`missing "gid: ID!" field in boundary type "Foo"`, withIDFieldName("gid", validateBoundaryObjectsFormat))
+	})
+
+	t.Run("valid boundary key", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+			sku: String!
+			name: String
+		}
+		`).assertValid(withIDFieldName("id", validateBoundaryObjectsFormat))
+	})
+
+	t.Run("missing key field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+		}
+		`).assertInvalid(`missing key field "sku" in boundary type "Product"`, withIDFieldName("id", validateBoundaryObjectsFormat))
+	})
+
+	t.Run("nullable key field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+			sku: String
+		}
+		`).assertInvalid(`key field "sku" of boundary type "Product" should be a non-null scalar without arguments`, withIDFieldName("id", validateBoundaryObjectsFormat))
 	})
 }
+
+func TestSchemaValidateKeyedBoundaryQueries(t *testing.T) {
+	t.Run("valid keyed boundary queries", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+			sku: String!
+		}
+
+		input ProductKey {
+			tenantId: ID!
+			sku: String!
+		}
+
+		type Query {
+			product(key: ProductKey!): Product @boundary
+			products(keys: [ProductKey!]!): [Product]! @boundary
+		}
+		`).assertValid(validateBoundaryQueries)
+	})
+
+	t.Run("single id key", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Dog @boundary(key: "uuid") {
+			uuid: ID!
+		}
+
+		type Query {
+			dog(uuid: ID!): Dog @boundary
+			dogs(uuids: [ID!]!): [Dog]! @boundary
+		}
+		`).assertValid(validateBoundaryQueries)
+	})
+
+	t.Run("id argument", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+			sku: String!
+		}
+
+		type Query {
+			product(id: ID!): Product @boundary
+		}
+		`).assertInvalid(`invalid boundary query "product": boundary query for "Product" must accept an input object with the key fields`, validateBoundaryQueries)
+	})
+
+	t.Run("input object not matching the key", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary(key: String) on OBJECT | FIELD_DEFINITION
+
+		type Product @boundary(key: "tenantId sku") {
+			tenantId: ID!
+			sku: String!
+		}
+
+		input ProductKey {
+			sku: String!
+		}
+
+		type Query {
+			product(key: ProductKey!): Product @boundary
+		}
+		`).assertInvalid(`invalid boundary query "product": input object "ProductKey" should have exactly the key fields of "Product"`, validateBoundaryQueries)
+	})
+}
+
+func TestRequiresDirective(t *testing.T) {
+	t.Run("valid required field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @requires(fields: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			summary(title: String!, year: Int!): String! @requires(fields: "title year")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertValid(validateRequiresDirective)
+	})
+
+	t.Run("non boundary object", func(t *testing.T) {
+		withSchema(t, `
+		directive @requires(fields: String!) on FIELD_DEFINITION
+
+		type Movie {
+			id: ID!
+			summary(title: String!): String! @requires(fields: "title")
+		}
+
+		type Query {
+			movie(id: ID!): Movie
+		}
+		`).assertInvalid("@requires directive on Movie.summary: only fields of boundary objects may require fields", validateRequiresDirective)
+	})
+
+	t.Run("missing argument", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @requires(fields: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			summary: String! @requires(fields: "title")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertInvalid(`@requires directive on Movie.summary: missing argument for required field "title"`, validateRequiresDirective)
+	})
+
+	t.Run("no required fields", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @requires(fields: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			summary: String! @requires(fields: "")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertInvalid("@requires directive on Movie.summary: no required fields", validateRequiresDirective)
+	})
+}
+
+func TestOwnerDirective(t *testing.T) {
+	t.Run("owned root field", func(t *testing.T) {
+		withSchema(t, `
+		directive @owner on FIELD_DEFINITION
+
+		type Query {
+			products: [String!]! @owner
+		}
+		`).assertValid(validateOwnerDirective)
+	})
+
+	t.Run("non root field", func(t *testing.T) {
+		withSchema(t, `
+		directive @owner on FIELD_DEFINITION
+
+		type Product {
+			name: String! @owner
+		}
+
+		type Query {
+			products: [Product!]!
+		}
+		`).assertInvalid("@owner directive on Product.name: only Query and Mutation fields can be owned", validateOwnerDirective)
+	})
+}
+
+func TestOverrideDirective(t *testing.T) {
+	validateOverride := func(schema *ast.Schema) error {
+		return validateOverrideDirective(schema, "id", "ratings")
+	}
+
+	t.Run("overridden field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @override(from: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			rating: Int @override(from: "movies")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertValid(validateOverride)
+	})
+
+	t.Run("key field", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @override(from: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID! @override(from: "movies")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertInvalid("@override directive on Movie.id: only non key fields of boundary objects can be overridden", validateOverride)
+	})
+
+	t.Run("missing service name", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @override(from: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			rating: Int @override(from: "")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertInvalid("@override directive on Movie.rating: missing service name", validateOverride)
+	})
+
+	t.Run("overriding itself", func(t *testing.T) {
+		withSchema(t, `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		directive @override(from: String!) on FIELD_DEFINITION
+
+		type Movie @boundary {
+			id: ID!
+			rating: Int @override(from: "ratings")
+		}
+
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+		`).assertInvalid(`@override directive on Movie.rating: service "ratings" can't override itself`, validateOverride)
+	})
+}
+
+func TestValidateOverrideSources(t *testing.T) {
+	movies := gqlparser.MustLoadSchema(&ast.Source{Name: "movies", Input: `
+		directive @boundary on OBJECT | FIELD_DEFINITION
+		type Movie @boundary {
+			id: ID!
+			rating: Int
+		}
+		type Query {
+			movie(id: ID!): Movie @boundary
+		}
+	`})
+	ratings := func(from string) *ast.Schema {
+		return gqlparser.MustLoadSchema(&ast.Source{Name: "ratings", Input: `
+			directive @boundary on OBJECT | FIELD_DEFINITION
+			directive @override(from: String!) on FIELD_DEFINITION
+			type Movie @boundary {
+				id: ID!
+				rating: Int @override(from: "` + from + `")
+			}
+			type Query {
+				movies(ids: [ID!]!): [Movie]! @boundary
+			}
+		`})
+	}
+	names := []string{"movies", "ratings"}
+
+	assert.NoError(t, validateOverrideSources([]*ast.Schema{movies, ratings("movies")}, names))
+	assert.EqualError(t, validateOverrideSources([]*ast.Schema{movies, ratings("films")}, names), `@override directive on Movie.rating: unknown service "films"`)
+	assert.EqualError(t, validateOverrideSources([]*ast.Schema{movies, ratings("ratings")}, names), `@override directive on Movie.rating: service "ratings" can't override itself`)
+
+	_, err := MergeSchemasWithOptions(SchemaOptions{ServiceNames: names}, movies, ratings("films"))
+	assert.EqualError(t, err, `@override directive on Movie.rating: unknown service "films"`)
+}